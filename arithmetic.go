@@ -0,0 +1,338 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"fmt"
+	gotime "time"
+)
+
+// Add a duration to a timestamp, keeping its time zone.
+//
+// UTC and UTC offset timestamps are simple arithmetic. Area/location
+// timestamps are converted to an instant using the time zone database, so
+// that the result accounts for any daylight savings transitions in between.
+// Local timestamps have no known UTC offset, so the duration is added to the
+// local clock as-is.
+//
+// Note: Latitude/longitude time zones can't be resolved unless a resolver has
+//       been set using SetLatLongResolver, and area/location timestamps whose
+//       local time doesn't exist or is ambiguous (due to daylight savings)
+//       can't be converted to an instant. These return an error wrapping
+//       ErrorUnresolvableTimezone, ErrorNonexistentLocalTime or
+//       ErrorAmbiguousLocalTime.
+// Note: Go time doesn't track leap seconds, so second 60 is treated as
+//       second 59 (unless the duration is 0).
+func (this *Time) Add(duration gotime.Duration) (result Time, err error) {
+	if err = this.checkArithmetic(); err != nil {
+		return
+	}
+	if duration == 0 {
+		result = *this
+		return
+	}
+
+	var wallClock gotime.Time
+	switch this.Timezone.Type {
+	case TimezoneTypeUTC, TimezoneTypeUTCOffset, TimezoneTypeLocal:
+		wallClock = this.wallClock().Add(duration)
+	default:
+		var instant gotime.Time
+		if instant, err = this.resolveInstant(); err != nil {
+			return
+		}
+		location, _ := this.Timezone.goLocation()
+		wallClock = instant.Add(duration).In(location)
+	}
+	result = *this
+	result.setWallClock(wallClock)
+	return
+}
+
+// Get the duration between two timestamps (this - that), which can be in
+// different time zones. See Add.
+//
+// Two local timestamps are compared by their local clocks, but a local
+// timestamp can't be compared to any other time zone.
+//
+// Note: Like go time, the result saturates at the minimum or maximum duration
+//       (about 292 years).
+func (this *Time) Sub(that Time) (duration gotime.Duration, err error) {
+	if err = this.checkArithmetic(); err != nil {
+		return
+	}
+	if err = that.checkArithmetic(); err != nil {
+		return
+	}
+	if this.Timezone.Type == TimezoneTypeLocal && that.Timezone.Type == TimezoneTypeLocal {
+		return this.wallClock().Sub(that.wallClock()), nil
+	}
+
+	var thisInstant, thatInstant gotime.Time
+	if thisInstant, err = this.resolveInstant(); err != nil {
+		return
+	}
+	if thatInstant, err = that.resolveInstant(); err != nil {
+		return
+	}
+	return thisInstant.Sub(thatInstant), nil
+}
+
+// How AddDate handles a day of the month that doesn't exist in the resulting
+// month (such as January 31st plus one month).
+type MonthEndPolicy int
+
+const (
+	// Use the last day of the month (January 31st + 1 month = February 28th
+	// or 29th)
+	MonthEndClamp MonthEndPolicy = iota
+	// Carry the extra days into the next month like go time does
+	// (January 31st + 1 month = March 2nd or 3rd)
+	MonthEndRollOver
+)
+
+// Add years, months, and days to a date or timestamp. The years and months are
+// added first (applying the month end policy), and then the days. Timestamps
+// keep their time zone and time of day.
+//
+// Note: There is no year 0, so adding 1 year to -1 gives 1.
+// Note: Area/location timestamps keep their local time even if it doesn't
+//       exist on the new date due to daylight savings.
+func (this *Time) AddDate(years, months, days int, policy MonthEndPolicy) (result Time, err error) {
+	if this.Type != TimeTypeDate && this.Type != TimeTypeTimestamp {
+		err = fmt.Errorf("%v: Only dates and timestamps support calendar arithmetic", this)
+		return
+	}
+	if this.IsZeroValue() {
+		err = fmt.Errorf("Cannot do arithmetic on a zero value")
+		return
+	}
+	if err = this.Validate(); err != nil {
+		return
+	}
+	switch policy {
+	case MonthEndClamp, MonthEndRollOver:
+	default:
+		err = fmt.Errorf("%v: Unknown month end policy", policy)
+		return
+	}
+
+	monthIndex := int(this.Month) - 1 + months
+	yearCarry := int(floorDiv(int64(monthIndex), 12))
+	year := astronomicalYear(this.Year) + years + yearCarry
+	month := gotime.Month(monthIndex - yearCarry*12 + 1)
+	day := int(this.Day)
+	if policy == MonthEndClamp {
+		if lastDay := daysInMonth(year, month); day > lastDay {
+			day = lastDay
+		}
+	}
+
+	date := gotime.Date(year, month, day+days, 0, 0, 0, 0, gotime.UTC)
+	result = *this
+	result.Year = historicalYear(date.Year())
+	result.Month = uint8(date.Month())
+	result.Day = uint8(date.Day())
+	return
+}
+
+// Add a duration to a time of day, wrapping around midnight. dayCarry is the
+// number of days that the result moved forward (or back, if negative).
+//
+// The time zone is kept, and no daylight savings adjustments are made since
+// there's no date to apply them to.
+//
+// A leap second (second 60) is one second long: Adding to it counts the leap
+// second, so 23:59:60 + 1s is 00:00:00 of the next day. Other times of day
+// have no way of knowing if there's a leap second coming, so 23:59:59 + 1s is
+// also 00:00:00 of the next day.
+func (this *Time) AddToTimeOfDay(duration gotime.Duration) (result Time, dayCarry int, err error) {
+	if err = this.checkTimeOfDay(); err != nil {
+		return
+	}
+	result = *this
+	if duration == 0 {
+		return
+	}
+
+	position := this.timeOfDayPosition() + duration
+	if this.Second == 60 {
+		leapSecondStart := this.timeOfDayPosition() - gotime.Duration(this.Nanosecond)
+		switch {
+		case position >= leapSecondStart+gotime.Second:
+			position -= gotime.Second
+		case position >= leapSecondStart:
+			result.Nanosecond = uint32(position - leapSecondStart)
+			result.fitPrecision()
+			return
+		}
+	}
+
+	dayCarry = int(floorDiv(int64(position), int64(timeOfDayLength)))
+	position -= gotime.Duration(dayCarry) * timeOfDayLength
+	result.Hour = uint8(position / gotime.Hour)
+	result.Minute = uint8(position % gotime.Hour / gotime.Minute)
+	result.Second = uint8(position % gotime.Minute / gotime.Second)
+	result.Nanosecond = uint32(position % gotime.Second)
+	result.fitPrecision()
+	return
+}
+
+// Get the signed difference between two times of day (this - that), without
+// wrapping around midnight. For example, 01:00 - 23:00 is -22 hours.
+//
+// If the time zones differ, that is converted to this time's time zone first
+// (wrapping around midnight if needed). Times of day have no date, so this
+// only works between UTC and UTC offset time zones. Otherwise the error wraps
+// ErrorUnresolvableTimezone.
+//
+// Note: A leap second (second 60) is at the same position as the start of the
+//       next minute.
+func (this *Time) SubTimeOfDay(that Time) (difference gotime.Duration, err error) {
+	if err = this.checkTimeOfDay(); err != nil {
+		return
+	}
+	if err = that.checkTimeOfDay(); err != nil {
+		return
+	}
+	var thatPosition gotime.Duration
+	if thatPosition, err = that.timeOfDayPositionIn(&this.Timezone); err != nil {
+		return
+	}
+	return this.timeOfDayPosition() - thatPosition, nil
+}
+
+// Check if a time of day is within the range from start (inclusive) to end
+// (exclusive). If end is before start, the range wraps around midnight (for
+// example, 22:00 to 02:00 contains 23:00 and 01:00). If start and end are the
+// same, the range is empty.
+//
+// end and time are converted to start's time zone as in SubTimeOfDay.
+func TimeOfDayRangeContains(start, end, time Time) (contains bool, err error) {
+	if err = start.checkTimeOfDay(); err != nil {
+		return
+	}
+	var endPosition, position gotime.Duration
+	if err = end.checkTimeOfDay(); err != nil {
+		return
+	}
+	if endPosition, err = end.timeOfDayPositionIn(&start.Timezone); err != nil {
+		return
+	}
+	if err = time.checkTimeOfDay(); err != nil {
+		return
+	}
+	if position, err = time.timeOfDayPositionIn(&start.Timezone); err != nil {
+		return
+	}
+
+	startPosition := start.timeOfDayPosition()
+	if startPosition <= endPosition {
+		return position >= startPosition && position < endPosition, nil
+	}
+	return position >= startPosition || position < endPosition, nil
+}
+
+// =============================================================================
+
+const timeOfDayLength = 24 * gotime.Hour
+
+func (this *Time) checkTimeOfDay() error {
+	if this.Type != TimeTypeTime {
+		return fmt.Errorf("%v: Only times support time of day arithmetic", this)
+	}
+	if this.IsZeroValue() {
+		return fmt.Errorf("Cannot do arithmetic on a zero value")
+	}
+	return this.Validate()
+}
+
+// Get the time since midnight. A leap second is at the same position as the
+// start of the next minute.
+func (this *Time) timeOfDayPosition() gotime.Duration {
+	return gotime.Duration(this.Hour)*gotime.Hour +
+		gotime.Duration(this.Minute)*gotime.Minute +
+		gotime.Duration(this.Second)*gotime.Second +
+		gotime.Duration(this.Nanosecond)
+}
+
+// Get the time since midnight in another time zone.
+func (this *Time) timeOfDayPositionIn(tz *Timezone) (position gotime.Duration, err error) {
+	position = this.timeOfDayPosition()
+	if this.Timezone.IsEquivalentTo(tz) {
+		return
+	}
+	var fromOffset, toOffset gotime.Duration
+	if fromOffset, err = this.Timezone.fixedOffset(); err != nil {
+		return
+	}
+	if toOffset, err = tz.fixedOffset(); err != nil {
+		return
+	}
+	// Wrap the leap second along with the second before it
+	var leapSecond gotime.Duration
+	if this.Second == 60 {
+		leapSecond = gotime.Second
+	}
+	position += toOffset - fromOffset - leapSecond
+	if position < 0 {
+		position += timeOfDayLength
+	} else if position >= timeOfDayLength {
+		position -= timeOfDayLength
+	}
+	position += leapSecond
+	return
+}
+
+// Get the UTC offset of a time zone that doesn't depend on the date.
+func (this *Timezone) fixedOffset() (offset gotime.Duration, err error) {
+	switch this.Type {
+	case TimezoneTypeUTC:
+		return 0, nil
+	case TimezoneTypeUTCOffset:
+		return gotime.Duration(this.MinutesOffsetFromUTC) * gotime.Minute, nil
+	default:
+		return 0, fmt.Errorf("%v: %w", this.String(), ErrorUnresolvableTimezone)
+	}
+}
+
+func floorDiv(a, b int64) int64 {
+	quotient := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		quotient--
+	}
+	return quotient
+}
+
+// Get the number of days in a month of an astronomical year (with a year 0).
+func daysInMonth(year int, month gotime.Month) int {
+	return gotime.Date(year, month+1, 0, 0, 0, 0, 0, gotime.UTC).Day()
+}
+
+func (this *Time) checkArithmetic() error {
+	if this.Type != TimeTypeTimestamp {
+		return fmt.Errorf("%v: Only timestamps support duration arithmetic", this)
+	}
+	if this.IsZeroValue() {
+		return fmt.Errorf("Cannot do arithmetic on a zero value")
+	}
+	return this.Validate()
+}
@@ -0,0 +1,270 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"errors"
+	"testing"
+	gotime "time"
+)
+
+func assertAdd(t *testing.T, time Time, duration gotime.Duration, expected Time) {
+	actual, err := time.Add(duration)
+	if err != nil {
+		t.Errorf("Error adding %v to %v: %v", duration, time, err)
+		return
+	}
+	if !expected.IsEquivalentTo(actual) {
+		t.Errorf("Expected %v + %v to be %v but got %v", time, duration, expected, actual)
+	}
+}
+
+func assertAddError(t *testing.T, time Time, duration gotime.Duration, expectedErr error) {
+	result, err := time.Add(duration)
+	if err == nil || expectedErr != nil && !errors.Is(err, expectedErr) {
+		t.Errorf("Expected adding %v to %v to fail with %v but got %v", duration, time, expectedErr, err)
+	}
+	if result != (Time{}) {
+		t.Errorf("Expected failing to add %v to %v to give the zero Time but got %v", duration, time, result)
+	}
+}
+
+func assertSub(t *testing.T, a, b Time, expected gotime.Duration) {
+	actual, err := a.Sub(b)
+	if err != nil {
+		t.Errorf("Error subtracting %v from %v: %v", b, a, err)
+		return
+	}
+	if actual != expected {
+		t.Errorf("Expected %v - %v to be %v but got %v", a, b, expected, actual)
+	}
+}
+
+func assertSubError(t *testing.T, a, b Time, expectedErr error) {
+	if _, err := a.Sub(b); err == nil || expectedErr != nil && !errors.Is(err, expectedErr) {
+		t.Errorf("Expected subtracting %v from %v to fail with %v but got %v", b, a, expectedErr, err)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	utc := TZAtUTC()
+	assertAdd(t, NewTimestamp(2020, 12, 31, 23, 0, 0, 0, utc), 90*gotime.Minute, NewTimestamp(2021, 1, 1, 0, 30, 0, 0, utc))
+	assertAdd(t, NewTimestamp(2020, 3, 1, 0, 0, 0, 500, utc), -gotime.Microsecond, NewTimestamp(2020, 2, 29, 23, 59, 59, 999999500, utc))
+	assertAdd(t, NewTimestamp(-1, 12, 31, 23, 59, 59, 0, utc), gotime.Second, NewTimestamp(1, 1, 1, 0, 0, 0, 0, utc))
+	assertAdd(t, NewTimestamp(2016, 12, 31, 23, 59, 60, 0, utc), 0, NewTimestamp(2016, 12, 31, 23, 59, 60, 0, utc))
+	assertAdd(t, NewTimestamp(2016, 12, 31, 23, 59, 60, 0, utc), gotime.Second, NewTimestamp(2017, 1, 1, 0, 0, 0, 0, utc))
+
+	offset := TZWithMiutesOffsetFromUTC(-330)
+	assertAdd(t, NewTimestamp(2020, 1, 1, 23, 45, 0, 0, offset), 30*gotime.Minute, NewTimestamp(2020, 1, 2, 0, 15, 0, 0, offset))
+
+	berlin := TZAtAreaLocation("Europe/Berlin")
+	assertAdd(t, NewTimestamp(2020, 3, 29, 1, 30, 0, 0, berlin), gotime.Hour, NewTimestamp(2020, 3, 29, 3, 30, 0, 0, berlin))
+	assertAdd(t, NewTimestamp(2020, 10, 25, 1, 30, 0, 0, berlin), 2*gotime.Hour, NewTimestamp(2020, 10, 25, 2, 30, 0, 0, berlin))
+	assertAdd(t, NewTimestamp(2020, 10, 25, 3, 30, 0, 0, berlin), -2*gotime.Hour, NewTimestamp(2020, 10, 25, 2, 30, 0, 0, berlin))
+	assertAdd(t, NewTimestamp(2020, 7, 1, 12, 0, 0, 0, berlin), 24*gotime.Hour*180, NewTimestamp(2020, 12, 28, 11, 0, 0, 0, berlin))
+
+	// Local time has no known offset, so DST doesn't apply
+	local := TZLocal()
+	assertAdd(t, NewTimestamp(2020, 3, 29, 1, 30, 0, 0, local), gotime.Hour, NewTimestamp(2020, 3, 29, 2, 30, 0, 0, local))
+
+	millis := withPrecision(NewTimestamp(2020, 1, 1, 0, 0, 0, 0, utc), PrecisionMilliseconds)
+	assertAdd(t, millis, gotime.Millisecond, withPrecision(NewTimestamp(2020, 1, 1, 0, 0, 0, 1000000, utc), PrecisionMilliseconds))
+	assertAdd(t, millis, gotime.Nanosecond, withPrecision(NewTimestamp(2020, 1, 1, 0, 0, 0, 1, utc), PrecisionNanoseconds))
+
+	assertAddError(t, NewTimestamp(2020, 3, 29, 2, 30, 0, 0, berlin), gotime.Hour, ErrorNonexistentLocalTime)
+	assertAddError(t, NewTimestamp(2020, 10, 25, 2, 30, 0, 0, berlin), gotime.Hour, ErrorAmbiguousLocalTime)
+	assertAddError(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtLatLong(5994, 1071)), gotime.Hour, ErrorUnresolvableTimezone)
+	assertAddError(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtAreaLocation("Nowhere/Special")), gotime.Hour, nil)
+	assertAddError(t, NewTime(10, 0, 0, 0, utc), gotime.Hour, nil)
+	assertAddError(t, NewDate(2020, 1, 1), gotime.Hour, nil)
+	assertAddError(t, ZeroTimestamp(), gotime.Hour, nil)
+	assertAddError(t, NewTimestamp(2020, 13, 1, 0, 0, 0, 0, utc), gotime.Hour, nil)
+}
+
+func TestSub(t *testing.T) {
+	utc := TZAtUTC()
+	berlin := TZAtAreaLocation("Europe/Berlin")
+	assertSub(t, NewTimestamp(2020, 1, 1, 12, 0, 0, 0, TZWithMiutesOffsetFromUTC(60)), NewTimestamp(2020, 1, 1, 11, 0, 0, 0, utc), 0)
+	assertSub(t, NewTimestamp(2021, 1, 1, 0, 30, 0, 0, utc), NewTimestamp(2020, 12, 31, 23, 0, 0, 0, utc), 90*gotime.Minute)
+	assertSub(t, NewTimestamp(2020, 3, 29, 3, 0, 0, 0, berlin), NewTimestamp(2020, 3, 29, 1, 0, 0, 0, berlin), gotime.Hour)
+	assertSub(t, NewTimestamp(2020, 10, 25, 3, 0, 0, 0, berlin), NewTimestamp(2020, 10, 25, 1, 0, 0, 0, berlin), 3*gotime.Hour)
+	assertSub(t, NewTimestamp(2020, 7, 1, 12, 0, 0, 0, berlin), NewTimestamp(2020, 7, 1, 12, 0, 0, 0, TZAtAreaLocation("America/New_York")), -6*gotime.Hour)
+	assertSub(t, NewTimestamp(2020, 3, 29, 3, 0, 0, 0, TZLocal()), NewTimestamp(2020, 3, 29, 1, 0, 0, 0, TZLocal()), 2*gotime.Hour)
+	assertSub(t, NewTimestamp(2017, 1, 1, 0, 0, 0, 0, utc), NewTimestamp(2016, 12, 31, 23, 59, 60, 0, utc), gotime.Second)
+	assertSub(t, NewTimestamp(5000, 1, 1, 0, 0, 0, 0, utc), NewTimestamp(1, 1, 1, 0, 0, 0, 0, utc), gotime.Duration(1<<63-1))
+
+	assertSubError(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZLocal()), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, utc), ErrorUnresolvableTimezone)
+	assertSubError(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, utc), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtLatLong(5994, 1071)), ErrorUnresolvableTimezone)
+	assertSubError(t, NewTimestamp(2020, 10, 25, 2, 30, 0, 0, berlin), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, utc), ErrorAmbiguousLocalTime)
+	assertSubError(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, utc), NewTime(10, 0, 0, 0, utc), nil)
+	assertSubError(t, ZeroTimestamp(), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, utc), nil)
+}
+
+func assertAddDate(t *testing.T, time Time, years, months, days int, policy MonthEndPolicy, expected Time) {
+	actual, err := time.AddDate(years, months, days, policy)
+	if err != nil {
+		t.Errorf("Error adding %v years, %v months, %v days to %v: %v", years, months, days, time, err)
+		return
+	}
+	if !expected.IsEquivalentTo(actual) {
+		t.Errorf("Expected %v + %v years, %v months, %v days to be %v but got %v", time, years, months, days, expected, actual)
+	}
+}
+
+func TestAddDate(t *testing.T) {
+	assertAddDate(t, NewDate(2020, 1, 31), 0, 1, 0, MonthEndClamp, NewDate(2020, 2, 29))
+	assertAddDate(t, NewDate(2021, 1, 31), 0, 1, 0, MonthEndClamp, NewDate(2021, 2, 28))
+	assertAddDate(t, NewDate(2021, 1, 31), 0, 1, 0, MonthEndRollOver, NewDate(2021, 3, 3))
+	assertAddDate(t, NewDate(2020, 1, 31), 0, 1, 0, MonthEndRollOver, NewDate(2020, 3, 2))
+	assertAddDate(t, NewDate(2020, 2, 29), 1, 0, 0, MonthEndClamp, NewDate(2021, 2, 28))
+	assertAddDate(t, NewDate(2020, 2, 29), 1, 0, 0, MonthEndRollOver, NewDate(2021, 3, 1))
+	assertAddDate(t, NewDate(2020, 3, 31), 0, -1, 0, MonthEndClamp, NewDate(2020, 2, 29))
+	assertAddDate(t, NewDate(2020, 1, 15), 0, -13, 0, MonthEndClamp, NewDate(2018, 12, 15))
+	assertAddDate(t, NewDate(2020, 1, 31), 0, 1, 1, MonthEndClamp, NewDate(2020, 3, 1))
+	assertAddDate(t, NewDate(2020, 12, 31), 0, 0, 1, MonthEndClamp, NewDate(2021, 1, 1))
+	assertAddDate(t, NewDate(2020, 1, 1), 0, 0, -365, MonthEndClamp, NewDate(2019, 1, 1))
+	assertAddDate(t, NewDate(2020, 5, 5), 0, 0, 0, MonthEndClamp, NewDate(2020, 5, 5))
+
+	// There is no year 0, and 1 BC (-1) is a leap year
+	assertAddDate(t, NewDate(-1, 6, 1), 1, 0, 0, MonthEndClamp, NewDate(1, 6, 1))
+	assertAddDate(t, NewDate(1, 6, 1), -1, 0, 0, MonthEndClamp, NewDate(-1, 6, 1))
+	assertAddDate(t, NewDate(1, 1, 1), 0, 0, -1, MonthEndClamp, NewDate(-1, 12, 31))
+	assertAddDate(t, NewDate(-1, 3, 31), 0, -1, 0, MonthEndClamp, NewDate(-1, 2, 29))
+	assertAddDate(t, NewDate(-2, 3, 31), 0, -1, 0, MonthEndClamp, NewDate(-2, 2, 28))
+	assertAddDate(t, NewDate(-50000, 1, 1), 52020, 0, 0, MonthEndClamp, NewDate(2021, 1, 1))
+
+	berlin := TZAtAreaLocation("Europe/Berlin")
+	assertAddDate(t, NewTimestamp(2020, 1, 31, 13, 45, 10, 5, berlin), 0, 1, 0, MonthEndClamp, NewTimestamp(2020, 2, 29, 13, 45, 10, 5, berlin))
+	assertAddDate(t, NewTimestamp(2016, 12, 31, 23, 59, 60, 0, TZAtUTC()), 0, 0, 1, MonthEndClamp, NewTimestamp(2017, 1, 1, 23, 59, 60, 0, TZAtUTC()))
+	assertAddDate(t, withPrecision(NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZLocal()), PrecisionMilliseconds), 0, 0, 1, MonthEndRollOver,
+		withPrecision(NewTimestamp(2020, 1, 2, 0, 0, 0, 0, TZLocal()), PrecisionMilliseconds))
+
+	invalid := []Time{NewTime(10, 0, 0, 0, TZAtUTC()), ZeroDate(), ZeroTimestamp(), NewDate(2020, 2, 30)}
+	for _, time := range invalid {
+		if _, err := time.AddDate(0, 1, 0, MonthEndClamp); err == nil {
+			t.Errorf("Expected adding a month to %v to fail", time)
+		}
+	}
+	date := NewDate(2020, 1, 1)
+	if _, err := date.AddDate(0, 1, 0, MonthEndPolicy(100)); err == nil {
+		t.Errorf("Expected an unknown month end policy to fail")
+	}
+}
+
+func assertAddToTimeOfDay(t *testing.T, time Time, duration gotime.Duration, expected Time, expectedCarry int) {
+	actual, carry, err := time.AddToTimeOfDay(duration)
+	if err != nil {
+		t.Errorf("Unexpected error adding %v to %v: %v", duration, time, err)
+		return
+	}
+	if !expected.IsEquivalentTo(actual) || carry != expectedCarry {
+		t.Errorf("Expected %v + %v to be %v with carry %v but got %v with carry %v", time, duration, expected, expectedCarry, actual, carry)
+	}
+}
+
+func TestAddToTimeOfDay(t *testing.T) {
+	utc := TZAtUTC()
+	berlin := TZAtAreaLocation("Europe/Berlin")
+	assertAddToTimeOfDay(t, NewTime(10, 0, 0, 0, utc), 90*gotime.Minute, NewTime(11, 30, 0, 0, utc), 0)
+	assertAddToTimeOfDay(t, NewTime(22, 0, 0, 0, berlin), 3*gotime.Hour, NewTime(1, 0, 0, 0, berlin), 1)
+	assertAddToTimeOfDay(t, NewTime(1, 0, 0, 0, utc), -2*gotime.Hour, NewTime(23, 0, 0, 0, utc), -1)
+	assertAddToTimeOfDay(t, NewTime(1, 0, 0, 0, utc), -50*gotime.Hour, NewTime(23, 0, 0, 0, utc), -3)
+	assertAddToTimeOfDay(t, NewTime(0, 0, 0, 0, utc), 48*gotime.Hour, NewTime(0, 0, 0, 0, utc), 2)
+	assertAddToTimeOfDay(t, NewTime(23, 59, 59, 0, utc), gotime.Second, NewTime(0, 0, 0, 0, utc), 1)
+	assertAddToTimeOfDay(t, NewTime(12, 0, 0, 0, TZAtLatLong(5994, 1071)), gotime.Nanosecond, NewTime(12, 0, 0, 1, TZAtLatLong(5994, 1071)), 0)
+
+	assertAddToTimeOfDay(t, NewTime(23, 59, 60, 0, utc), 0, NewTime(23, 59, 60, 0, utc), 0)
+	assertAddToTimeOfDay(t, NewTime(23, 59, 60, 0, utc), gotime.Second, NewTime(0, 0, 0, 0, utc), 1)
+	assertAddToTimeOfDay(t, NewTime(23, 59, 60, 0, utc), 500*gotime.Millisecond, NewTime(23, 59, 60, 500000000, utc), 0)
+	assertAddToTimeOfDay(t, NewTime(23, 59, 60, 500000000, utc), -500*gotime.Millisecond, NewTime(23, 59, 60, 0, utc), 0)
+	assertAddToTimeOfDay(t, NewTime(23, 59, 60, 0, utc), -gotime.Second, NewTime(23, 59, 59, 0, utc), 0)
+	assertAddToTimeOfDay(t, NewTime(8, 59, 60, 0, TZWithMiutesOffsetFromUTC(540)), 2*gotime.Second, NewTime(9, 0, 1, 0, TZWithMiutesOffsetFromUTC(540)), 0)
+
+	assertAddToTimeOfDay(t, withPrecision(NewTime(10, 0, 0, 0, utc), PrecisionMilliseconds), gotime.Microsecond,
+		withPrecision(NewTime(10, 0, 0, 1000, utc), PrecisionMicroseconds), 0)
+
+	for _, time := range []Time{
+		NewTimestamp(2020, 1, 1, 0, 0, 0, 0, utc),
+		NewDate(2020, 1, 1),
+		ZeroTime(),
+		NewTime(24, 0, 0, 0, utc),
+	} {
+		if _, _, err := time.AddToTimeOfDay(gotime.Hour); err == nil {
+			t.Errorf("Expected adding to the time of day of %v to fail", time)
+		}
+	}
+}
+
+func TestSubTimeOfDay(t *testing.T) {
+	utc := TZAtUTC()
+	assertSubTimeOfDay := func(a, b Time, expected gotime.Duration) {
+		actual, err := a.SubTimeOfDay(b)
+		if err != nil || actual != expected {
+			t.Errorf("Expected %v - %v to be %v but got %v (%v)", a, b, expected, actual, err)
+		}
+	}
+	assertSubTimeOfDay(NewTime(17, 30, 0, 0, utc), NewTime(9, 0, 0, 0, utc), 8*gotime.Hour+30*gotime.Minute)
+	assertSubTimeOfDay(NewTime(1, 0, 0, 0, utc), NewTime(23, 0, 0, 0, utc), -22*gotime.Hour)
+	assertSubTimeOfDay(NewTime(12, 0, 0, 0, TZWithMiutesOffsetFromUTC(60)), NewTime(11, 0, 0, 0, utc), 0)
+	assertSubTimeOfDay(NewTime(0, 30, 0, 0, TZWithMiutesOffsetFromUTC(60)), NewTime(23, 0, 0, 0, utc), 30*gotime.Minute)
+	assertSubTimeOfDay(NewTime(23, 0, 0, 0, utc), NewTime(0, 30, 0, 0, TZWithMiutesOffsetFromUTC(60)), -30*gotime.Minute)
+	assertSubTimeOfDay(NewTime(10, 0, 0, 0, TZAtAreaLocation("Asia/Tokyo")), NewTime(9, 0, 0, 0, TZAtAreaLocation("Asia/Tokyo")), gotime.Hour)
+	assertSubTimeOfDay(NewTime(23, 59, 60, 0, utc), NewTime(23, 59, 59, 0, utc), gotime.Second)
+	assertSubTimeOfDay(NewTime(8, 59, 60, 0, TZWithMiutesOffsetFromUTC(540)), NewTime(23, 59, 59, 0, utc), gotime.Second)
+
+	tokyo := NewTime(10, 0, 0, 0, TZAtAreaLocation("Asia/Tokyo"))
+	if _, err := tokyo.SubTimeOfDay(NewTime(1, 0, 0, 0, utc)); !errors.Is(err, ErrorUnresolvableTimezone) {
+		t.Errorf("Expected subtracting across an area/location time zone to fail with %v but got %v", ErrorUnresolvableTimezone, err)
+	}
+	if _, err := tokyo.SubTimeOfDay(NewTimestamp(2020, 1, 1, 0, 0, 0, 0, utc)); err == nil {
+		t.Errorf("Expected subtracting a timestamp from a time to fail")
+	}
+}
+
+func TestTimeOfDayRangeContains(t *testing.T) {
+	utc := TZAtUTC()
+	assertContains := func(start, end, time Time, expected bool) {
+		actual, err := TimeOfDayRangeContains(start, end, time)
+		if err != nil || actual != expected {
+			t.Errorf("Expected %v to %v containing %v to be %v but got %v (%v)", start, end, time, expected, actual, err)
+		}
+	}
+	nineToFive := []Time{NewTime(9, 0, 0, 0, utc), NewTime(17, 0, 0, 0, utc)}
+	assertContains(nineToFive[0], nineToFive[1], NewTime(9, 0, 0, 0, utc), true)
+	assertContains(nineToFive[0], nineToFive[1], NewTime(16, 59, 59, 999999999, utc), true)
+	assertContains(nineToFive[0], nineToFive[1], NewTime(17, 0, 0, 0, utc), false)
+	assertContains(nineToFive[0], nineToFive[1], NewTime(8, 0, 0, 0, utc), false)
+	assertContains(nineToFive[0], nineToFive[1], NewTime(12, 0, 0, 0, TZWithMiutesOffsetFromUTC(120)), true)
+	assertContains(nineToFive[0], nineToFive[1], NewTime(20, 0, 0, 0, TZWithMiutesOffsetFromUTC(120)), false)
+
+	night := []Time{NewTime(22, 0, 0, 0, utc), NewTime(2, 0, 0, 0, utc)}
+	assertContains(night[0], night[1], NewTime(23, 0, 0, 0, utc), true)
+	assertContains(night[0], night[1], NewTime(1, 0, 0, 0, utc), true)
+	assertContains(night[0], night[1], NewTime(23, 59, 60, 0, utc), true)
+	assertContains(night[0], night[1], NewTime(12, 0, 0, 0, utc), false)
+	assertContains(night[0], night[1], NewTime(2, 0, 0, 0, utc), false)
+	assertContains(night[0], night[1], NewTime(0, 30, 0, 0, TZWithMiutesOffsetFromUTC(60)), true)
+	assertContains(night[0], night[0], NewTime(22, 0, 0, 0, utc), false)
+
+	if _, err := TimeOfDayRangeContains(night[0], night[1], NewTime(23, 0, 0, 0, TZLocal())); !errors.Is(err, ErrorUnresolvableTimezone) {
+		t.Errorf("Expected checking a local time against a UTC range to fail with %v but got %v", ErrorUnresolvableTimezone, err)
+	}
+}
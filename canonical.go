@@ -0,0 +1,154 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// A value can be encoded in more than one way that decodes to the same thing.
+// The canonical encoding is the one that this library's encoder produces, and
+// the strict decoders reject everything else with a DecodeError whose cause
+// is one of the following errors (all of which also match ErrorNonCanonical
+// using errors.Is).
+//
+// The canonical subsecond magnitude is the smallest one that can hold the
+// nanoseconds. A time with a declared Precision that's larger than this still
+// encodes and decodes normally, but it isn't canonical and so can't be strictly
+// decoded.
+var (
+	ErrorNonCanonical          = fmt.Errorf("Compact time value is not in canonical form")
+	ErrorNonCanonicalMagnitude = fmt.Errorf("%w: Subsecond magnitude is larger than needed", ErrorNonCanonical)
+	ErrorNonCanonicalYear      = fmt.Errorf("%w: Year has unneeded ULEB128 groups", ErrorNonCanonical)
+	ErrorNonCanonicalZeroValue = fmt.Errorf("%w: Zero value has non-zero fields", ErrorNonCanonical)
+	ErrorNonCanonicalTimezone  = fmt.Errorf("%w: Time zone is not in its shortest form", ErrorNonCanonical)
+)
+
+// Decode a date from the start of src like DecodeDateFromBytes, but reject
+// values that are invalid or not in canonical form.
+func DecodeDateFromBytesStrict(src []byte) (time Time, bytesDecoded int, err error) {
+	if time, bytesDecoded, err = DecodeDateFromBytes(src); err == nil {
+		err = checkCanonical(&time, src[:bytesDecoded])
+	}
+	return
+}
+
+// Decode a time value from the start of src like DecodeTimeFromBytes, but
+// reject values that are invalid or not in canonical form.
+//
+// Note: The UTC time zone is canonically encoded by leaving out the time zone,
+// so any time zone that decodes to UTC (such as "Z", "Etc/UTC" or a UTC
+// offset of 0) is rejected.
+func DecodeTimeFromBytesStrict(src []byte) (time Time, bytesDecoded int, err error) {
+	if time, bytesDecoded, err = DecodeTimeFromBytes(src); err == nil {
+		err = checkCanonical(&time, src[:bytesDecoded])
+	}
+	return
+}
+
+// Decode a timestamp from the start of src like DecodeTimestampFromBytes, but
+// reject values that are invalid or not in canonical form. See
+// DecodeTimeFromBytesStrict.
+func DecodeTimestampFromBytesStrict(src []byte) (time Time, bytesDecoded int, err error) {
+	if time, bytesDecoded, err = DecodeTimestampFromBytes(src); err == nil {
+		err = checkCanonical(&time, src[:bytesDecoded])
+	}
+	return
+}
+
+// Check if src holds exactly one canonically encoded date.
+func IsCanonicalDate(src []byte) bool {
+	_, bytesDecoded, err := DecodeDateFromBytesStrict(src)
+	return err == nil && bytesDecoded == len(src)
+}
+
+// Check if src holds exactly one canonically encoded time value.
+func IsCanonicalTime(src []byte) bool {
+	_, bytesDecoded, err := DecodeTimeFromBytesStrict(src)
+	return err == nil && bytesDecoded == len(src)
+}
+
+// Check if src holds exactly one canonically encoded timestamp.
+func IsCanonicalTimestamp(src []byte) bool {
+	_, bytesDecoded, err := DecodeTimestampFromBytesStrict(src)
+	return err == nil && bytesDecoded == len(src)
+}
+
+// =============================================================================
+
+// Check that src (which time was decoded from) is exactly what the encoder
+// would produce for time.
+func checkCanonical(time *Time, src []byte) error {
+	magnitude := 0
+	if time.Type != TimeTypeDate {
+		magnitude = decodeMagnitude(src[0])
+		if magnitude != getSubsecondMagnitude(int(time.Nanosecond)) {
+			return newDecodeError(DecodeFieldMagnitude, 0, ErrorNonCanonicalMagnitude)
+		}
+	}
+
+	var timezoneOffset int
+	switch time.Type {
+	case TimeTypeDate:
+		timezoneOffset = byteCountDate
+	case TimeTypeTime:
+		timezoneOffset = baseByteCountsTime[magnitude]
+	case TimeTypeTimestamp:
+		timezoneOffset = baseByteCountsTimestamp[magnitude]
+	}
+	if time.Type != TimeTypeTime {
+		yearOffset := timezoneOffset
+		timezoneOffset, _ = yearGroupsEnd(src, yearOffset)
+		if timezoneOffset-yearOffset > 1 && src[timezoneOffset-1] == 0 {
+			return newDecodeError(DecodeFieldYear, yearOffset, ErrorNonCanonicalYear)
+		}
+	}
+
+	if time.IsZeroValue() {
+		if len(src) != byteCountsZeroValue[time.Type] || !isAllZeroes(src) {
+			return newDecodeError(DecodeFieldHeader, 0, ErrorNonCanonicalZeroValue)
+		}
+		return nil
+	}
+
+	if time.Type != TimeTypeDate {
+		var buffer [1 + 127]byte
+		byteCount := time.encodeTimezone(buffer[:])
+		if !bytes.Equal(src[timezoneOffset:], buffer[:byteCount]) {
+			return newDecodeError(DecodeFieldTimezone, timezoneOffset, ErrorNonCanonicalTimezone)
+		}
+	}
+
+	if err := time.Validate(); err != nil {
+		return newDecodeError(DecodeFieldHeader, 0, err)
+	}
+	return nil
+}
+
+func isAllZeroes(src []byte) bool {
+	for _, b := range src {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,147 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func decodeAnyTypeStrict(timeType TimeType, src []byte) (time Time, bytesDecoded int, err error) {
+	switch timeType {
+	case TimeTypeDate:
+		return DecodeDateFromBytesStrict(src)
+	case TimeTypeTime:
+		return DecodeTimeFromBytesStrict(src)
+	default:
+		return DecodeTimestampFromBytesStrict(src)
+	}
+}
+
+func isCanonicalAnyType(timeType TimeType, src []byte) bool {
+	switch timeType {
+	case TimeTypeDate:
+		return IsCanonicalDate(src)
+	case TimeTypeTime:
+		return IsCanonicalTime(src)
+	default:
+		return IsCanonicalTimestamp(src)
+	}
+}
+
+func assertNonCanonical(t *testing.T, timeType TimeType, data []byte, expectedErr error, expectedField DecodeField, expectedOffset int) {
+	if _, _, err := decodeAnyTypeFromBytes(timeType, data); err != nil {
+		t.Errorf("Expected %x to decode without strict checking but got %v", data, err)
+	}
+	_, _, err := decodeAnyTypeStrict(timeType, data)
+	var decodeErr *DecodeError
+	if !errors.Is(err, expectedErr) || !errors.As(err, &decodeErr) ||
+		decodeErr.Field != expectedField || decodeErr.Offset != expectedOffset {
+		t.Errorf("Expected strict decoding of %x to fail with %v in %v at offset %v but got %v",
+			data, expectedErr, expectedField, expectedOffset, err)
+	}
+	if isCanonicalAnyType(timeType, data) {
+		t.Errorf("Expected %x not to be canonical", data)
+	}
+}
+
+func TestNonCanonical(t *testing.T) {
+	assertNonCanonical(t, TimeTypeTime, []byte{0x02, 0x00, 0x00, 0xd4}, ErrorNonCanonicalMagnitude, DecodeFieldMagnitude, 0)
+	assertNonCanonical(t, TimeTypeTimestamp, []byte{0x06, 0x28, 0x6b, 0xee, 0x9c, 0xf0, 0x79, 0x44, 0x01}, ErrorNonCanonicalMagnitude, DecodeFieldMagnitude, 0)
+	assertNonCanonical(t, TimeTypeTime, []byte{0x02, 0x00, 0x00, 0x00}, ErrorNonCanonicalMagnitude, DecodeFieldMagnitude, 0)
+
+	assertNonCanonical(t, TimeTypeDate, []byte{0x21, 0x00, 0x80, 0x00}, ErrorNonCanonicalYear, DecodeFieldYear, 2)
+	assertNonCanonical(t, TimeTypeTimestamp, []byte{0x70, 0xc2, 0xe7, 0x11, 0x85, 0x80, 0x00}, ErrorNonCanonicalYear, DecodeFieldYear, 4)
+
+	assertNonCanonical(t, TimeTypeTimestamp, []byte{0x00, 0x80, 0x00, 0x00, 0x00}, ErrorNonCanonicalZeroValue, DecodeFieldHeader, 0)
+
+	assertNonCanonical(t, TimeTypeTimestamp, []byte{0x71, 0xc2, 0xe7, 0x11, 0x05, 0x02, 0x5a}, ErrorNonCanonicalTimezone, DecodeFieldTimezone, 5)
+	assertNonCanonical(t, TimeTypeTimestamp, []byte{0x71, 0xc2, 0xe7, 0x11, 0x05, 0x0a, 'L', 'o', 'c', 'a', 'l'}, ErrorNonCanonicalTimezone, DecodeFieldTimezone, 5)
+	assertNonCanonical(t, TimeTypeTime, []byte{0x01, 0x00, 0xf5, 0x08, 'Z', 'e', 'r', 'o'}, ErrorNonCanonicalTimezone, DecodeFieldTimezone, 3)
+	assertNonCanonical(t, TimeTypeTime, []byte{0x01, 0x00, 0xf5, 0x00, 0x00, 0x00}, ErrorNonCanonicalTimezone, DecodeFieldTimezone, 3)
+	assertNonCanonical(t, TimeTypeTime, []byte{0x01, 0x00, 0xf5, 0x00, 0xc4, 0x0f}, ErrorNonCanonicalTimezone, DecodeFieldTimezone, 3)
+	assertNonCanonical(t, TimeTypeTime, append([]byte{0x01, 0x00, 0xf5, 0x20}, "America/New_York"...), ErrorNonCanonicalTimezone, DecodeFieldTimezone, 3)
+}
+
+func TestCanonical(t *testing.T) {
+	canonical := []struct {
+		timeType TimeType
+		data     []byte
+	}{
+		{TimeTypeTime, []byte{0x00, 0x00, 0xf5}},
+		{TimeTypeTimestamp, []byte{0xa2, 0xcf, 0x09, 0x9f, 0x47, 0x14}},
+		{TimeTypeDate, []byte{0x21, 0x00, 0x00}},
+		{TimeTypeTimestamp, []byte{0x71, 0xc2, 0xe7, 0x11, 0x05, 0x02, 'L'}},
+		{TimeTypeTime, []byte{0x01, 0x00, 0xf5, 0x00, 0xc4, 0xff}},
+		{TimeTypeTime, append([]byte{0x01, 0x00, 0xf5, 0x14}, "A/New_York"...)},
+	}
+	for _, value := range canonical {
+		if !isCanonicalAnyType(value.timeType, value.data) {
+			_, _, err := decodeAnyTypeStrict(value.timeType, value.data)
+			t.Errorf("Expected %x to be canonical but got %v", value.data, err)
+		}
+		if isCanonicalAnyType(value.timeType, append(value.data, 0)) {
+			t.Errorf("Expected %x with trailing data not to be canonical", value.data)
+		}
+	}
+
+	invalid := []byte{0x70, 0xc2, 0xe7, 0x1b, 0x05}
+	if _, _, err := DecodeTimestampFromBytesStrict(invalid); err == nil || errors.Is(err, ErrorNonCanonical) {
+		t.Errorf("Expected strict decoding of invalid month to fail validation but got %v", err)
+	}
+}
+
+func TestCanonicalMatchesEncoder(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	values := []Time{ZeroDate(), ZeroTime(), ZeroTimestamp(), NewDate(-50000, 12, 31), NewDate(2020, 8, 30)}
+	for i := 0; i < 200; i++ {
+		timestamp := randomTimestamp(random)
+		values = append(values, timestamp, NewTime(int(timestamp.Hour), int(timestamp.Minute),
+			int(timestamp.Second), int(timestamp.Nanosecond), TZAtLatLong(random.Intn(18001)-9000, random.Intn(36001)-18000)))
+	}
+
+	for _, value := range values {
+		if value.Timezone.Type == TimezoneTypeUTCOffset && value.Timezone.MinutesOffsetFromUTC == 0 {
+			// Canonically encoded as UTC
+			continue
+		}
+		encoded := encodeAll(t, value)
+		if !isCanonicalAnyType(value.Type, encoded) {
+			_, _, err := decodeAnyTypeStrict(value.Type, encoded)
+			t.Errorf("Expected encoding of %v to be canonical but got %v", value, err)
+		}
+
+		// Anything accepted after corrupting a byte must re-encode identically
+		for i := 0; i < 20; i++ {
+			corrupted := append([]byte{}, encoded...)
+			corrupted[random.Intn(len(corrupted))] ^= byte(1 << uint(random.Intn(8)))
+			decoded, bytesDecoded, err := decodeAnyTypeStrict(value.Type, corrupted)
+			if err != nil {
+				continue
+			}
+			if reencoded := encodeAll(t, decoded); !bytes.Equal(reencoded, corrupted[:bytesDecoded]) {
+				t.Errorf("Strict decoding accepted %x as %v, which encodes to %x", corrupted[:bytesDecoded], decoded, reencoded)
+			}
+		}
+	}
+}
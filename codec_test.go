@@ -22,7 +22,9 @@ package compact_time
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"testing"
 	gotime "time"
 
@@ -147,6 +149,40 @@ func assertEncodeDecode(t *testing.T, expectedTime Time, expectValid bool, expec
 				expectedTime, describe.D(expectedBytes), err)
 		}
 	}
+
+	// Encoded length from every prefix
+	assertEncodedLength(t, expectedTime.Type, expectedBytes, len(expectedBytes))
+
+	// Appending after existing data
+	prefix := []byte{0xaa, 0xbb}
+	appended, err := expectedTime.AppendEncoded(prefix)
+	if err != nil && expectValid {
+		t.Errorf("Error appending %v: %v", expectedTime, err)
+		return
+	}
+	if err == nil && !bytes.Equal(appended, append(prefix, expectedBytes...)) {
+		t.Errorf("Expected %v to append %v but got %v", expectedTime,
+			describe.D(expectedBytes), describe.D(appended))
+		return
+	}
+
+	// Decoding in place, with trailing data that must be left alone
+	srcBytes := append(append([]byte{}, expectedBytes...), 0xff)
+	actualTime, decodedCount, err = decodeAnyTypeFromBytes(expectedTime.Type, srcBytes)
+	if err != nil {
+		t.Errorf("Error attempting to decode %v in place to %v: %v",
+			describe.D(srcBytes), expectedTime, err)
+		return
+	}
+	if decodedCount != len(expectedBytes) {
+		t.Errorf("Expected %v (%v) to have decoded byte count of %v in place but got %v",
+			expectedTime, describe.D(srcBytes), len(expectedBytes), decodedCount)
+		return
+	}
+	if !expectedTime.IsEquivalentTo(actualTime) {
+		t.Errorf("Expected %v to decode in place to %v but got %v",
+			describe.D(srcBytes), expectedTime, actualTime)
+	}
 }
 
 func TestDate(t *testing.T) {
@@ -228,8 +264,401 @@ func TestTimestampLocal(t *testing.T) {
 	assertEncodeDecode(t, NewTimestamp(2000, 1, 1, 0, 0, 0, 0, TZAtAreaLocation("L")), true, []byte{0x01, 0x00, 0x10, 0x02, 0x00, 0x02, 0x4c})
 }
 
+func withPrecision(time Time, precision Precision) Time {
+	time.Precision = precision
+	return time
+}
+
+func TestPrecision(t *testing.T) {
+	assertEncodeDecode(t, withPrecision(NewTime(10, 0, 0, 0, TZAtUTC()), PrecisionMilliseconds), true, []byte{0x02, 0x00, 0x00, 0xd4})
+	assertEncodeDecode(t, withPrecision(NewTime(10, 0, 0, 0, TZAtUTC()), PrecisionSeconds), true, []byte{0x00, 0x00, 0xf5})
+	assertEncodeDecode(t, withPrecision(NewTimestamp(2020, 8, 30, 15, 33, 14, 500000000, TZAtUTC()), PrecisionNanoseconds), true, []byte{0x06, 0x28, 0x6b, 0xee, 0x9c, 0xf0, 0x79, 0x44, 0x01})
+	assertEncodeDecode(t, withPrecision(NewTimestamp(2000, 1, 1, 0, 0, 0, 999000, TZAtUTC()), PrecisionMicroseconds), true, []byte{0x3c, 0x1f, 0x00, 0x00, 0x00, 0x21, 0x00, 0x00})
+
+	decoded, _, err := DecodeTimeFromBytes([]byte{0x5a, 0xc1, 0x93, 0xdc})
+	if err != nil || decoded.Precision != PrecisionMilliseconds {
+		t.Errorf("Expected decoding to fill in precision %v but got %v (%v)", PrecisionMilliseconds, decoded.Precision, err)
+	}
+	decoded, _, err = DecodeTimestampFromBytes([]byte{0x00, 0x00, 0x10, 0x02, 0x00})
+	if err != nil || decoded.Precision != PrecisionSeconds {
+		t.Errorf("Expected decoding to fill in precision %v but got %v (%v)", PrecisionSeconds, decoded.Precision, err)
+	}
+
+	unknownPrecision := withPrecision(NewTimestamp(2020, 8, 30, 15, 33, 14, 0, TZAtUTC()), 7)
+	if size := unknownPrecision.EncodedSize(); size != 9 {
+		t.Errorf("Expected the size of %v with unknown precision to be clamped to 9 but got %v", unknownPrecision, size)
+	}
+	if _, err := unknownPrecision.AppendEncoded(nil); err == nil {
+		t.Errorf("Expected encoding %v with unknown precision to fail", unknownPrecision)
+	}
+
+	tooPrecise := withPrecision(NewTime(10, 0, 0, 1000, TZAtUTC()), PrecisionMilliseconds)
+	if _, err := tooPrecise.AppendEncoded(nil); err == nil {
+		t.Errorf("Expected encoding %v with precision %v to fail", tooPrecise, tooPrecise.Precision)
+	}
+}
+
 func TestZeroValues(t *testing.T) {
 	assertEncodeDecode(t, ZeroDate(), false, []byte{0x00, 0x00, 0x00})
 	assertEncodeDecode(t, ZeroTime(), false, []byte{0x00, 0x00, 0x00})
 	assertEncodeDecode(t, ZeroTimestamp(), false, []byte{0x00, 0x00, 0x00, 0x00, 0x00})
 }
+
+func assertEncodeGoTimestamp(t *testing.T, src gotime.Time, expectedBytes []byte) {
+	expectedSize := len(expectedBytes)
+	if actualSize := EncodedSizeGoTimestamp(src); actualSize != expectedSize {
+		t.Errorf("Expected %v to have encoded size of %v but got %v", src, expectedSize, actualSize)
+	}
+	actualBytes := &bytes.Buffer{}
+	if _, err := EncodeGoTimestamp(src, actualBytes); err != nil {
+		t.Errorf("Error encoding %v: %v", src, err)
+		return
+	}
+	if !bytes.Equal(expectedBytes, actualBytes.Bytes()) {
+		t.Errorf("Expected %v to encode to %v but got %v", src,
+			describe.D(expectedBytes), describe.D(actualBytes.Bytes()))
+	}
+	appended, err := AppendEncodedGoTimestamp([]byte{0xaa}, src)
+	if err != nil {
+		t.Errorf("Error appending %v: %v", src, err)
+		return
+	}
+	if !bytes.Equal(appended, append([]byte{0xaa}, expectedBytes...)) {
+		t.Errorf("Expected %v to append %v but got %v", src,
+			describe.D(expectedBytes), describe.D(appended))
+	}
+}
+
+func assertEncodeGoTime(t *testing.T, src gotime.Time, expectedBytes []byte) {
+	expectedSize := len(expectedBytes)
+	if actualSize := EncodedSizeGoTime(src); actualSize != expectedSize {
+		t.Errorf("Expected %v to have encoded size of %v but got %v", src, expectedSize, actualSize)
+	}
+	actualBytes := &bytes.Buffer{}
+	if _, err := EncodeGoTime(src, actualBytes); err != nil {
+		t.Errorf("Error encoding %v: %v", src, err)
+		return
+	}
+	if !bytes.Equal(expectedBytes, actualBytes.Bytes()) {
+		t.Errorf("Expected %v to encode to %v but got %v", src,
+			describe.D(expectedBytes), describe.D(actualBytes.Bytes()))
+	}
+	appended, err := AppendEncodedGoTime([]byte{0xaa}, src)
+	if err != nil {
+		t.Errorf("Error appending %v: %v", src, err)
+		return
+	}
+	if !bytes.Equal(appended, append([]byte{0xaa}, expectedBytes...)) {
+		t.Errorf("Expected %v to append %v but got %v", src,
+			describe.D(expectedBytes), describe.D(appended))
+	}
+}
+
+func TestEncodeGo(t *testing.T) {
+	singapore, err := gotime.LoadLocation("Asia/Singapore")
+	if err != nil {
+		t.Fatalf("Error loading location: %v", err)
+	}
+	assertEncodeGoTimestamp(t, gotime.Date(2020, 8, 30, 15, 33, 14, 19577323, singapore), []byte{0x5f, 0xcf, 0x55, 0x09, 0x9c, 0xf0, 0x79, 0x44, 0x01, 0x16, 'S', '/', 'S', 'i', 'n', 'g', 'a', 'p', 'o', 'r', 'e'})
+	assertEncodeGoTimestamp(t, gotime.Date(1966, 12, 1, 5, 13, 5, 0, gotime.UTC), []byte{0x28, 0x9a, 0x12, 0x78, 0x08})
+	assertEncodeGoTimestamp(t, gotime.Date(2000, 1, 1, 0, 0, 0, 0, gotime.FixedZone("", 1000*60)), []byte{0x01, 0x00, 0x10, 0x02, 0x00, 0x00, 0xe8, 0x03})
+	assertEncodeGoTimestamp(t, gotime.Date(2000, 1, 1, 0, 0, 0, 0, gotime.FixedZone("-01", -60*60)), []byte{0x01, 0x00, 0x10, 0x02, 0x00, 0x00, 0xc4, 0xff})
+	assertEncodeGoTime(t, gotime.Date(2000, 1, 1, 8, 41, 05, 999999999, gotime.FixedZone("", -500*60)), []byte{0xff, 0x4f, 0xd6, 0xdc, 0x8b, 0x14, 0xfd, 0x00, 0x0c, 0xfe})
+	assertEncodeGoTime(t, gotime.Date(2000, 1, 1, 14, 18, 30, 43000000, gotime.UTC), []byte{0x5a, 0xc1, 0x93, 0xdc})
+
+	buffer := &bytes.Buffer{}
+	_, err = EncodeGoTimestamp(gotime.Date(2000, 1, 1, 0, 0, 0, 0, gotime.FixedZone("Not A Zone", -60*60)), buffer)
+	if !errors.Is(err, ErrorInvalidLocationName) {
+		t.Errorf("Expected encoding an invalid location name to fail with %v but got %v", ErrorInvalidLocationName, err)
+	}
+	if expected := []byte{0x01, 0x00, 0x10, 0x02, 0x00, 0x00, 0xc4, 0xff}; !bytes.Equal(expected, buffer.Bytes()) {
+		t.Errorf("Expected invalid location name to fall back to offset %v but got %v", describe.D(expected), describe.D(buffer.Bytes()))
+	}
+
+	for _, offsetMinutes := range []int{1440, 1500, -1440} {
+		src := gotime.Date(2000, 1, 1, 0, 0, 0, 0, gotime.FixedZone("", offsetMinutes*60))
+		buffer.Reset()
+		if _, err := EncodeGoTimestamp(src, buffer); err == nil || buffer.Len() != 0 {
+			t.Errorf("Expected encoding %v as a timestamp to fail without writing but got %v (%v)", src, describe.D(buffer.Bytes()), err)
+		}
+		if _, err := EncodeGoTime(src, buffer); err == nil || buffer.Len() != 0 {
+			t.Errorf("Expected encoding %v as a time to fail without writing but got %v (%v)", src, describe.D(buffer.Bytes()), err)
+		}
+		bytesEncoded, err := EncodeGoTimestampToBytes(src, make([]byte, EncodedSizeGoTimestamp(src)))
+		if err == nil || bytesEncoded != 0 {
+			t.Errorf("Expected encoding %v as a timestamp to fail but encoded %v bytes (%v)", src, bytesEncoded, err)
+		}
+		bytesEncoded, err = EncodeGoTimeToBytes(src, make([]byte, EncodedSizeGoTime(src)))
+		if err == nil || bytesEncoded != 0 {
+			t.Errorf("Expected encoding %v as a time to fail but encoded %v bytes (%v)", src, bytesEncoded, err)
+		}
+		prefix := []byte{0xaa}
+		if appended, err := AppendEncodedGoTimestamp(prefix, src); err == nil || !bytes.Equal(appended, prefix) {
+			t.Errorf("Expected appending %v as a timestamp to fail but got %v (%v)", src, describe.D(appended), err)
+		}
+		if appended, err := AppendEncodedGoTime(prefix, src); err == nil || !bytes.Equal(appended, prefix) {
+			t.Errorf("Expected appending %v as a time to fail but got %v (%v)", src, describe.D(appended), err)
+		}
+	}
+}
+
+func TestAppendEncoded(t *testing.T) {
+	date := gotime.Date(2020, 8, 30, 15, 33, 14, 0, gotime.UTC)
+	if actual, expected := AppendEncodedGoDate([]byte{0xaa}, date), []byte{0xaa, 0x1e, 0x51, 0x00}; !bytes.Equal(actual, expected) {
+		t.Errorf("Expected %v to append as date %v but got %v", date, describe.D(expected), describe.D(actual))
+	}
+
+	invalidValues := []Time{
+		{Type: TimeType(10), Timezone: TZAtUTC()},
+		NewTime(10, 10, 10, 0, Timezone{Type: TimezoneType(20)}),
+		NewTimestamp(2020, 13, 1, 0, 0, 0, 0, TZAtUTC()),
+		NewTime(24, 0, 0, 0, TZAtUTC()),
+	}
+	prefix := []byte{0xaa}
+	for _, value := range invalidValues {
+		actual, err := value.AppendEncoded(prefix)
+		if err == nil {
+			t.Errorf("Expected appending %v to fail but got %v", value, describe.D(actual))
+		}
+		if !bytes.Equal(actual, prefix) {
+			t.Errorf("Expected failed append of %v to leave %v but got %v", value, describe.D(prefix), describe.D(actual))
+		}
+	}
+}
+
+func TestAppendEncodedAllocations(t *testing.T) {
+	dst := make([]byte, 0, MaxEncodeLength)
+	values := []Time{
+		NewDate(2020, 8, 30),
+		ZeroTimestamp(),
+		NewTime(13, 41, 5, 999999999, TZWithMiutesOffsetFromUTC(-330)),
+		NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZAtUTC()),
+		NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZAtAreaLocation("Asia/Singapore")),
+		NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZAtLatLong(5994, 1071)),
+	}
+	for _, value := range values {
+		allocs := testing.AllocsPerRun(100, func() {
+			value.AppendEncoded(dst[:0])
+		})
+		if allocs != 0 {
+			t.Errorf("Expected appending %v to make no allocations but got %v", value, allocs)
+		}
+	}
+
+	goTimes := []gotime.Time{
+		gotime.Date(2020, 8, 30, 15, 33, 14, 19577323, gotime.UTC),
+		gotime.Date(2020, 8, 30, 15, 33, 14, 19577323, gotime.FixedZone("", -330*60)),
+	}
+	for _, goTime := range goTimes {
+		allocs := testing.AllocsPerRun(100, func() {
+			AppendEncodedGoDate(dst[:0], goTime)
+			AppendEncodedGoTime(dst[:0], goTime)
+			AppendEncodedGoTimestamp(dst[:0], goTime)
+		})
+		if allocs != 0 {
+			t.Errorf("Expected appending %v to make no allocations but got %v", goTime, allocs)
+		}
+	}
+}
+
+func decodeAnyType(timeType TimeType, data []byte) (err error) {
+	reader := bytes.NewBuffer(data)
+	switch timeType {
+	case TimeTypeDate:
+		_, _, err = DecodeDate(reader)
+	case TimeTypeTime:
+		_, _, err = DecodeTime(reader)
+	case TimeTypeTimestamp:
+		_, _, err = DecodeTimestamp(reader)
+	}
+	return
+}
+
+func encodedLengthOf(timeType TimeType, prefix []byte) (needed int, complete bool) {
+	switch timeType {
+	case TimeTypeDate:
+		return EncodedLengthDate(prefix)
+	case TimeTypeTime:
+		return EncodedLengthTime(prefix)
+	default:
+		return EncodedLengthTimestamp(prefix)
+	}
+}
+
+func assertEncodedLength(t *testing.T, timeType TimeType, data []byte, expectedLength int) {
+	for prefixLength := 0; prefixLength <= len(data); prefixLength++ {
+		prefix := data[:prefixLength]
+		needed, complete := encodedLengthOf(timeType, prefix)
+		if complete {
+			if needed != expectedLength {
+				t.Errorf("Expected %v prefix %v to give length %v but got %v", timeType, describe.D(prefix), expectedLength, needed)
+			}
+			continue
+		}
+		if prefixLength >= expectedLength {
+			t.Errorf("Expected %v prefix %v to be complete", timeType, describe.D(prefix))
+		} else if needed <= prefixLength || needed > expectedLength {
+			t.Errorf("Expected %v prefix %v to need between %v and %v bytes but got %v", timeType, describe.D(prefix), prefixLength+1, expectedLength, needed)
+		}
+	}
+}
+
+func TestEncodedLengthInvalid(t *testing.T) {
+	assertEncodedLength(t, TimeTypeTime, []byte{0x5b, 0xc1, 0x93, 0x5c, 0x00}, 4)
+	assertEncodedLength(t, TimeTypeDate, []byte{0x21, 0x00, 0xff, 0xff, 0xff, 0xff, 0x7f}, 7)
+	assertEncodedLength(t, TimeTypeTimestamp, []byte{0x28, 0x9a, 0x12, 0x78, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, 14)
+	assertEncodedLength(t, TimeTypeTime, []byte{0x51, 0x14, 0xf5, 0xfe}, 131)
+}
+
+func assertDecodeError(t *testing.T, timeType TimeType, data []byte, expectedField DecodeField, expectedOffset int, isIncomplete bool) {
+	_, _, bytesErr := decodeAnyTypeFromBytes(timeType, data)
+	for _, err := range []error{decodeAnyType(timeType, data), bytesErr} {
+		var decodeErr *DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Errorf("Expected decoding %v to fail with a DecodeError but got %v", describe.D(data), err)
+			continue
+		}
+		if decodeErr.Field != expectedField || decodeErr.Offset != expectedOffset {
+			t.Errorf("Expected decoding %v to fail in %v at offset %v but got %v", describe.D(data), expectedField, expectedOffset, err)
+		}
+		if errors.Is(err, ErrorIncomplete) != isIncomplete || errors.Is(err, io.ErrUnexpectedEOF) != isIncomplete {
+			t.Errorf("Expected decoding %v incomplete status to be %v but got %v", describe.D(data), isIncomplete, err)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, timeType := range []TimeType{TimeTypeDate, TimeTypeTime, TimeTypeTimestamp} {
+		if err := decodeAnyType(timeType, []byte{}); err != io.EOF {
+			t.Errorf("Expected decoding empty data as %v to give io.EOF but got %v", timeType, err)
+		}
+		if _, _, err := decodeAnyTypeFromBytes(timeType, []byte{}); err != io.EOF {
+			t.Errorf("Expected decoding empty bytes as %v to give io.EOF but got %v", timeType, err)
+		}
+	}
+
+	assertDecodeError(t, TimeTypeDate, []byte{0x21}, DecodeFieldHeader, 1, true)
+	assertDecodeError(t, TimeTypeDate, []byte{0x21, 0x00}, DecodeFieldYear, 2, true)
+	assertDecodeError(t, TimeTypeDate, []byte{0x21, 0x00, 0x80}, DecodeFieldYear, 3, true)
+	assertDecodeError(t, TimeTypeDate, []byte{0x21, 0x00, 0xff, 0xff, 0xff, 0xff, 0x7f}, DecodeFieldYear, 2, false)
+
+	assertDecodeError(t, TimeTypeTime, []byte{0xfe, 0x4f, 0xd6}, DecodeFieldMagnitude, 3, true)
+	assertDecodeError(t, TimeTypeTime, []byte{0x5a, 0xc1, 0x93, 0x5c}, DecodeFieldMagnitude, 0, false)
+	assertDecodeError(t, TimeTypeTime, []byte{0x51, 0x14, 0xf5}, DecodeFieldTimezone, 3, true)
+	assertDecodeError(t, TimeTypeTime, []byte{0x51, 0x14, 0xf5, 0x0e, 'S', '/'}, DecodeFieldTimezone, 6, true)
+	assertDecodeError(t, TimeTypeTime, []byte{0x51, 0x14, 0xf5, 0x6d, 0xf5}, DecodeFieldLatLong, 5, true)
+	assertDecodeError(t, TimeTypeTime, []byte{0xff, 0x4f, 0xd6, 0xdc, 0x8b, 0x14, 0xfd, 0x00, 0xe8}, DecodeFieldUTCOffset, 9, true)
+
+	assertDecodeError(t, TimeTypeTimestamp, []byte{0x28}, DecodeFieldMagnitude, 1, true)
+	assertDecodeError(t, TimeTypeTimestamp, []byte{0x28, 0x9a, 0x12, 0x78}, DecodeFieldYear, 4, true)
+	assertDecodeError(t, TimeTypeTimestamp, []byte{0x01, 0x00, 0x10, 0x02, 0x00}, DecodeFieldTimezone, 5, true)
+}
+
+func benchmarkDecodeFromBytes(b *testing.B, value Time) {
+	src := &bytes.Buffer{}
+	if _, err := value.Encode(src); err != nil {
+		b.Fatal(err)
+	}
+	encoded := src.Bytes()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := decodeAnyTypeFromBytes(value.Type, encoded); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeDateFromBytes(b *testing.B) {
+	benchmarkDecodeFromBytes(b, NewDate(2020, 8, 30))
+}
+
+func BenchmarkDecodeTimeFromBytesUTC(b *testing.B) {
+	benchmarkDecodeFromBytes(b, NewTime(13, 41, 5, 999999999, TZAtUTC()))
+}
+
+func BenchmarkDecodeTimeFromBytesOffset(b *testing.B) {
+	benchmarkDecodeFromBytes(b, NewTime(13, 41, 5, 999999999, TZWithMiutesOffsetFromUTC(-330)))
+}
+
+func BenchmarkDecodeTimestampFromBytesUTC(b *testing.B) {
+	benchmarkDecodeFromBytes(b, NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZAtUTC()))
+}
+
+func BenchmarkDecodeTimestampFromBytesOffset(b *testing.B) {
+	benchmarkDecodeFromBytes(b, NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZWithMiutesOffsetFromUTC(540)))
+}
+
+func BenchmarkDecodeTimestampFromBytesUTCAlias(b *testing.B) {
+	benchmarkDecodeFromBytes(b, NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZAtAreaLocation("Etc/GMT")))
+}
+
+func TestDecodeFromBytesAllocations(t *testing.T) {
+	values := []Time{
+		NewDate(-2000, 12, 21),
+		NewTime(13, 41, 5, 999999999, TZAtUTC()),
+		NewTime(13, 41, 5, 999999999, TZWithMiutesOffsetFromUTC(-330)),
+		NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZAtUTC()),
+		NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZWithMiutesOffsetFromUTC(540)),
+		NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZLocal()),
+		NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZAtAreaLocation("Etc/GMT")),
+		NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZAtLatLong(5994, 1071)),
+	}
+	for _, value := range values {
+		src := &bytes.Buffer{}
+		if _, err := value.Encode(src); err != nil {
+			t.Errorf("Error encoding %v: %v", value, err)
+			continue
+		}
+		encoded := src.Bytes()
+		allocs := testing.AllocsPerRun(100, func() {
+			decodeAnyTypeFromBytes(value.Type, encoded)
+		})
+		if allocs != 0 {
+			t.Errorf("Expected decoding %v in place to make no allocations but got %v", value, allocs)
+		}
+	}
+}
+
+func benchmarkAppendEncoded(b *testing.B, value Time) {
+	dst := make([]byte, 0, MaxEncodeLength)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := value.AppendEncoded(dst[:0]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAppendEncodedDate(b *testing.B) {
+	benchmarkAppendEncoded(b, NewDate(2020, 8, 30))
+}
+
+func BenchmarkAppendEncodedTimeUTC(b *testing.B) {
+	benchmarkAppendEncoded(b, NewTime(13, 41, 5, 999999999, TZAtUTC()))
+}
+
+func BenchmarkAppendEncodedTimestampUTC(b *testing.B) {
+	benchmarkAppendEncoded(b, NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZAtUTC()))
+}
+
+func BenchmarkAppendEncodedTimestampOffset(b *testing.B) {
+	benchmarkAppendEncoded(b, NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZWithMiutesOffsetFromUTC(540)))
+}
+
+func BenchmarkAppendEncodedTimestampAreaLocation(b *testing.B) {
+	benchmarkAppendEncoded(b, NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZAtAreaLocation("Asia/Singapore")))
+}
+
+func BenchmarkAppendEncodedGoTimestamp(b *testing.B) {
+	dst := make([]byte, 0, MaxEncodeLength)
+	src := gotime.Date(2020, 8, 30, 15, 33, 14, 19577323, gotime.UTC)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := AppendEncodedGoTimestamp(dst[:0], src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"fmt"
+
+	"github.com/kstenerud/go-uleb128"
+)
+
+// Column encoding stores an array of timestamps more compactly than encoding
+// them one by one. It is not part of the compact time spec. The layout is:
+//
+//     count:     ULEB128 number of timestamps
+//     magnitude: 1 byte, the finest subsecond magnitude of any timestamp
+//     base:      zigzag ULEB128 year of the first timestamp, then ULEB128
+//                packed month, day, hour, minute and second, then ULEB128
+//                subseconds
+//     deltas:    count-1 differences between each timestamp and the one
+//                before it. Normally this is a ULEB128 of the zigzag delta in
+//                subsecond units, shifted left 1. If the low bit is set, the
+//                rest is the zigzag delta in seconds, followed by a ULEB128 of
+//                the (absolute) subseconds. This handles huge gaps, such as
+//                to or from a zero value.
+//     time zones: runs of (ULEB128 run length, TimezoneType byte, data)
+//     precisions: runs of (ULEB128 run length, Precision byte)
+//
+// Deltas are taken over the fields (as if each field were a digit in a
+// mixed radix number), not over the instant, so they don't depend on the time
+// zones and every field value round-trips exactly. Time zone data is:
+//
+//     UTC, local, area/location: ULEB128 length + short area/location, then
+//                                ULEB128 length + long area/location
+//     lat/long:                  same as the spec encoding
+//     UTC offset:                16-bit little endian minutes
+//     unset:                     nothing
+//
+// Zero value timestamps are allowed in a column. All other timestamps must
+// pass Validate().
+
+// Encode an array of timestamps as a column.
+func EncodeTimestampColumn(times []Time) (encoded []byte, err error) {
+	magnitude := 0
+	for i := range times {
+		if err = validateColumnTimestamp(&times[i]); err != nil {
+			return
+		}
+		if m := times[i].subsecondMagnitude(); m > magnitude {
+			magnitude = m
+		}
+	}
+
+	encoded = appendULEB128(encoded, uint64(len(times)))
+	if len(times) == 0 {
+		return
+	}
+	encoded = append(encoded, byte(magnitude))
+
+	base := &times[0]
+	encoded = appendULEB128(encoded, uint64(encodeZigzag64(int64(base.Year))))
+	encoded = appendULEB128(encoded, uint64(packColumnFields(base)))
+	encoded = appendULEB128(encoded, uint64(columnSubseconds(base, magnitude)))
+
+	for i := 1; i < len(times); i++ {
+		encoded = appendColumnDelta(encoded, &times[i-1], &times[i], magnitude)
+	}
+
+	for runStart := 0; runStart < len(times); {
+		tz := &times[runStart].Timezone
+		runEnd := runStart + 1
+		for runEnd < len(times) && isSameColumnTimezone(tz, &times[runEnd].Timezone) {
+			runEnd++
+		}
+		encoded = appendULEB128(encoded, uint64(runEnd-runStart))
+		encoded = appendColumnTimezone(encoded, tz)
+		runStart = runEnd
+	}
+
+	for runStart := 0; runStart < len(times); {
+		precision := times[runStart].Precision
+		runEnd := runStart + 1
+		for runEnd < len(times) && times[runEnd].Precision == precision {
+			runEnd++
+		}
+		encoded = appendULEB128(encoded, uint64(runEnd-runStart))
+		encoded = append(encoded, byte(precision))
+		runStart = runEnd
+	}
+	return
+}
+
+// Decode a column of timestamps that was encoded by EncodeTimestampColumn.
+func DecodeTimestampColumn(src []byte) (times []Time, bytesDecoded int, err error) {
+	decoder := columnDecoder{src: src}
+	count := int(decoder.readULEB128(DecodeFieldHeader))
+	if decoder.err == nil && count > len(src) {
+		// Every value takes at least a byte
+		decoder.require(DecodeFieldHeader, len(src)+1)
+	}
+	if decoder.err != nil || count == 0 {
+		return nil, decoder.pos, decoder.err
+	}
+
+	magnitude := int(decoder.readByte(DecodeFieldMagnitude))
+	if decoder.err == nil && magnitude > 3 {
+		decoder.fail(DecodeFieldMagnitude, fmt.Errorf("%v: Invalid magnitude", magnitude))
+	}
+	baseYear := decodeZigzag64(decoder.readULEB128(DecodeFieldYear))
+	basePacked := int64(decoder.readULEB128(DecodeFieldHeader))
+	baseSubseconds := int64(decoder.readULEB128(DecodeFieldHeader))
+	if decoder.err != nil {
+		return nil, decoder.pos, decoder.err
+	}
+	if basePacked > maskColumnFields || baseSubseconds >= columnUnitsPerSecond[magnitude] {
+		decoder.fail(DecodeFieldHeader, fmt.Errorf("Invalid base timestamp"))
+		return nil, decoder.pos, decoder.err
+	}
+
+	times = make([]Time, count)
+	unitsPerSecond := columnUnitsPerSecond[magnitude]
+	tick := columnTick{seconds: baseYear<<columnFieldsBitCount | basePacked, subseconds: baseSubseconds}
+	tick.apply(&times[0], magnitude)
+	for i := 1; i < count && decoder.err == nil; i++ {
+		encodedDelta := decoder.readULEB128(DecodeFieldColumnDelta)
+		delta := decodeZigzag64(encodedDelta >> 1)
+		if encodedDelta&1 == 1 {
+			tick.seconds += delta
+			tick.subseconds = int64(decoder.readULEB128(DecodeFieldColumnDelta))
+			if decoder.err == nil && tick.subseconds >= unitsPerSecond {
+				decoder.fail(DecodeFieldColumnDelta, fmt.Errorf("%v: Invalid subseconds", tick.subseconds))
+			}
+		} else {
+			tick.subseconds += delta % unitsPerSecond
+			tick.seconds += delta / unitsPerSecond
+			if tick.subseconds < 0 {
+				tick.subseconds += unitsPerSecond
+				tick.seconds--
+			} else if tick.subseconds >= unitsPerSecond {
+				tick.subseconds -= unitsPerSecond
+				tick.seconds++
+			}
+		}
+		tick.apply(&times[i], magnitude)
+	}
+
+	for index := 0; index < count && decoder.err == nil; {
+		runLength := int(decoder.readULEB128(DecodeFieldTimezone))
+		tz := decoder.readColumnTimezone()
+		if decoder.err != nil {
+			break
+		}
+		if runLength == 0 || runLength > count-index {
+			decoder.fail(DecodeFieldTimezone, fmt.Errorf("%v: Invalid time zone run length", runLength))
+			break
+		}
+		for end := index + runLength; index < end; index++ {
+			times[index].Timezone = tz
+		}
+	}
+
+	for index := 0; index < count && decoder.err == nil; {
+		runLength := int(decoder.readULEB128(DecodeFieldColumnPrecision))
+		precision := Precision(decoder.readByte(DecodeFieldColumnPrecision))
+		if decoder.err != nil {
+			break
+		}
+		if runLength == 0 || runLength > count-index {
+			decoder.fail(DecodeFieldColumnPrecision, fmt.Errorf("%v: Invalid precision run length", runLength))
+			break
+		}
+		if precision > PrecisionNanoseconds {
+			decoder.fail(DecodeFieldColumnPrecision, fmt.Errorf("%v: Invalid precision", precision))
+			break
+		}
+		for end := index + runLength; index < end; index++ {
+			times[index].Precision = precision
+		}
+	}
+	if decoder.err != nil {
+		return nil, decoder.pos, decoder.err
+	}
+	return times, decoder.pos, nil
+}
+
+// =============================================================================
+
+const columnFieldsBitCount = sizeMonth + sizeDay + sizeHour + sizeMinute + sizeSecond
+const maskColumnFields = (1 << columnFieldsBitCount) - 1
+
+var columnUnitsPerSecond = [...]int64{1, 1000, 1000000, 1000000000}
+
+func validateColumnTimestamp(time *Time) error {
+	if time.Type != TimeTypeTimestamp {
+		return fmt.Errorf("%v: Column values must be timestamps", time.Type)
+	}
+	if time.IsZeroValue() {
+		return nil
+	}
+	if int64(int32(time.Year)) != int64(time.Year) {
+		return fmt.Errorf("%v: Year is too big", time.Year)
+	}
+	return time.Validate()
+}
+
+// Pack the fields below the year into a single number, each field taking as
+// many bits as it does in the spec encoding.
+func packColumnFields(time *Time) int64 {
+	accumulator := int64(time.Month)
+	accumulator = (accumulator << sizeDay) | int64(time.Day)
+	accumulator = (accumulator << sizeHour) | int64(time.Hour)
+	accumulator = (accumulator << sizeMinute) | int64(time.Minute)
+	accumulator = (accumulator << sizeSecond) | int64(time.Second)
+	return accumulator
+}
+
+// Set the fields below the year from a number packed by packColumnFields.
+func unpackColumnFields(time *Time, packed int64) {
+	time.Second = uint8(packed & maskSecond)
+	packed >>= sizeSecond
+	time.Minute = uint8(packed & maskMinute)
+	packed >>= sizeMinute
+	time.Hour = uint8(packed & maskHour)
+	packed >>= sizeHour
+	time.Day = uint8(packed & maskDay)
+	packed >>= sizeDay
+	time.Month = uint8(packed)
+}
+
+func columnSubseconds(time *Time, magnitude int) int64 {
+	return int64(time.Nanosecond) * columnUnitsPerSecond[magnitude] / 1000000000
+}
+
+func appendColumnDelta(dst []byte, previous, current *Time, magnitude int) []byte {
+	secondsDelta := (int64(current.Year)-int64(previous.Year))<<columnFieldsBitCount +
+		packColumnFields(current) - packColumnFields(previous)
+	subseconds := columnSubseconds(current, magnitude)
+	unitsPerSecond := columnUnitsPerSecond[magnitude]
+
+	// Keep the delta within 62 bits so that the zigzag value can be shifted.
+	if limit := (1<<62)/unitsPerSecond - 1; secondsDelta > limit || secondsDelta < -limit {
+		dst = appendULEB128(dst, encodeZigzag64(secondsDelta)<<1|1)
+		return appendULEB128(dst, uint64(subseconds))
+	}
+	delta := secondsDelta*unitsPerSecond + subseconds - columnSubseconds(previous, magnitude)
+	return appendULEB128(dst, encodeZigzag64(delta)<<1)
+}
+
+type columnTick struct {
+	seconds    int64
+	subseconds int64
+}
+
+func (this *columnTick) apply(time *Time, magnitude int) {
+	time.Type = TimeTypeTimestamp
+	time.Year = int(this.seconds >> columnFieldsBitCount)
+	unpackColumnFields(time, this.seconds&maskColumnFields)
+	time.Nanosecond = uint32(this.subseconds * (1000000000 / columnUnitsPerSecond[magnitude]))
+}
+
+func isSameColumnTimezone(a, b *Timezone) bool {
+	if a.Type != b.Type {
+		return false
+	}
+	switch a.Type {
+	case TimezoneTypeUTC, TimezoneTypeLocal, TimezoneTypeAreaLocation:
+		return a.ShortAreaLocation == b.ShortAreaLocation && a.LongAreaLocation == b.LongAreaLocation
+	}
+	return a.IsEquivalentTo(b)
+}
+
+func appendColumnTimezone(dst []byte, tz *Timezone) []byte {
+	dst = append(dst, byte(tz.Type))
+	switch tz.Type {
+	case TimezoneTypeUTC, TimezoneTypeLocal, TimezoneTypeAreaLocation:
+		dst = appendULEB128(dst, uint64(len(tz.ShortAreaLocation)))
+		dst = append(dst, tz.ShortAreaLocation...)
+		dst = appendULEB128(dst, uint64(len(tz.LongAreaLocation)))
+		dst = append(dst, tz.LongAreaLocation...)
+	case TimezoneTypeLatitudeLongitude:
+		var buffer []byte
+		dst, buffer = growBytes(dst, byteCountLatLong)
+		encodeTimezoneLatLong(int(tz.LatitudeHundredths), int(tz.LongitudeHundredths), buffer)
+	case TimezoneTypeUTCOffset:
+		var buffer []byte
+		dst, buffer = growBytes(dst, 2)
+		encode16LE(uint16(tz.MinutesOffsetFromUTC), buffer)
+	}
+	return dst
+}
+
+func appendULEB128(dst []byte, value uint64) []byte {
+	var buffer [10]byte
+	byteCount := uleb128.EncodeUint64ToBytes(value, buffer[:])
+	return append(dst, buffer[:byteCount]...)
+}
+
+func encodeZigzag64(value int64) uint64 {
+	return uint64((value >> 63) ^ (value << 1))
+}
+
+func decodeZigzag64(value uint64) int64 {
+	return int64((value >> 1) ^ -(value & 1))
+}
+
+// Reads the parts of a column, recording the first error encountered. Once
+// an error has occurred, all reads return zero values.
+type columnDecoder struct {
+	src []byte
+	pos int
+	err error
+}
+
+func (this *columnDecoder) fail(field DecodeField, cause error) {
+	if this.err == nil {
+		this.err = newDecodeError(field, this.pos, cause)
+	}
+}
+
+func (this *columnDecoder) require(field DecodeField, byteCount int) bool {
+	if this.err != nil {
+		return false
+	}
+	if len(this.src)-this.pos < byteCount {
+		this.pos = len(this.src)
+		this.fail(field, ErrorIncomplete)
+		return false
+	}
+	return true
+}
+
+func (this *columnDecoder) readByte(field DecodeField) byte {
+	if !this.require(field, 1) {
+		return 0
+	}
+	this.pos++
+	return this.src[this.pos-1]
+}
+
+func (this *columnDecoder) readBytes(field DecodeField, byteCount int) []byte {
+	if !this.require(field, byteCount) {
+		return nil
+	}
+	this.pos += byteCount
+	return this.src[this.pos-byteCount : this.pos]
+}
+
+func (this *columnDecoder) readULEB128(field DecodeField) (value uint64) {
+	for shift := uint(0); this.require(field, 1); shift += 7 {
+		group := this.src[this.pos]
+		if shift > 63 || (shift == 63 && group > 1) {
+			this.fail(field, fmt.Errorf("Value is too big"))
+			return 0
+		}
+		this.pos++
+		value |= uint64(group&0x7f) << shift
+		if group&0x80 == 0 {
+			return
+		}
+	}
+	return 0
+}
+
+func (this *columnDecoder) readString(field DecodeField) string {
+	length := this.readULEB128(field)
+	if this.err == nil && length > uint64(len(this.src)-this.pos) {
+		this.require(field, len(this.src)-this.pos+1)
+		return ""
+	}
+	return string(this.readBytes(field, int(length)))
+}
+
+func (this *columnDecoder) readColumnTimezone() (tz Timezone) {
+	tzType := TimezoneType(this.readByte(DecodeFieldTimezone))
+	switch tzType {
+	case TimezoneTypeUnset:
+	case TimezoneTypeUTC, TimezoneTypeLocal, TimezoneTypeAreaLocation:
+		tz.ShortAreaLocation = this.readString(DecodeFieldTimezone)
+		tz.LongAreaLocation = this.readString(DecodeFieldTimezone)
+		tz.Type = tzType
+	case TimezoneTypeLatitudeLongitude:
+		if src := this.readBytes(DecodeFieldLatLong, byteCountLatLong); src != nil {
+			tz = decodeLatLong(src)
+		}
+	case TimezoneTypeUTCOffset:
+		if src := this.readBytes(DecodeFieldUTCOffset, 2); src != nil {
+			tz.Type = TimezoneTypeUTCOffset
+			tz.MinutesOffsetFromUTC = int16(decode16LE(src))
+		}
+	default:
+		this.fail(DecodeFieldTimezone, fmt.Errorf("%v: Unknown timezone type", tzType))
+	}
+	return
+}
@@ -0,0 +1,133 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kstenerud/go-describe"
+)
+
+func assertColumnRoundTrip(t *testing.T, times []Time) []byte {
+	encoded, err := EncodeTimestampColumn(times)
+	if err != nil {
+		t.Errorf("Error encoding column %v: %v", times, err)
+		return nil
+	}
+	decoded, bytesDecoded, err := DecodeTimestampColumn(encoded)
+	if err != nil {
+		t.Errorf("Error decoding column %v from %v: %v", times, describe.D(encoded), err)
+		return nil
+	}
+	if bytesDecoded != len(encoded) {
+		t.Errorf("Expected column %v to decode %v bytes but got %v", times, len(encoded), bytesDecoded)
+	}
+	if len(decoded) != len(times) {
+		t.Errorf("Expected column %v to decode %v values but got %v", times, len(times), len(decoded))
+		return nil
+	}
+	for i, expected := range times {
+		if decoded[i] != expected {
+			t.Errorf("Expected column value %v to be %#v but got %#v", i, expected, decoded[i])
+		}
+	}
+	return encoded
+}
+
+func TestTimestampColumn(t *testing.T) {
+	assertColumnRoundTrip(t, []Time{})
+	assertColumnRoundTrip(t, []Time{NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZAtUTC())})
+	assertColumnRoundTrip(t, []Time{
+		NewTimestamp(2020, 12, 31, 23, 59, 59, 999000000, TZAtAreaLocation("America/New_York")),
+		NewTimestamp(2020, 12, 31, 23, 59, 60, 0, TZAtAreaLocation("America/New_York")),
+		NewTimestamp(2021, 1, 1, 0, 0, 0, 1, TZAtAreaLocation("America/New_York")),
+		NewTimestamp(2020, 2, 29, 12, 0, 0, 0, TZAtAreaLocation("Etc/GMT")),
+		NewTimestamp(2020, 2, 29, 12, 0, 0, 0, TZAtUTC()),
+		ZeroTimestamp(),
+		NewTimestamp(-1, 1, 1, 0, 0, 0, 0, TZLocal()),
+		NewTimestamp(1, 1, 1, 0, 0, 0, 0, TZAtLatLong(-9000, 18000)),
+		NewTimestamp(1, 1, 1, 0, 0, 0, 0, TZWithMiutesOffsetFromUTC(-1439)),
+		NewTimestamp(1, 1, 1, 0, 0, 0, 0, Timezone{Type: TimezoneTypeUTCOffset}),
+		NewTimestamp(-50000, 6, 15, 1, 2, 3, 4000, TZAtAreaLocation("E/Berlin")),
+		NewTimestamp(50000, 6, 15, 1, 2, 3, 0, TZAtAreaLocation("E/Berlin")),
+		NewTimestamp(-2147483648, 6, 15, 1, 2, 3, 999999999, TZAtAreaLocation("E/Berlin")),
+		NewTimestamp(2147483647, 6, 15, 1, 2, 3, 0, TZAtAreaLocation("E/Berlin")),
+	})
+	assertColumnRoundTrip(t, []Time{
+		withPrecision(NewTimestamp(2020, 8, 30, 15, 33, 14, 100000000, TZAtUTC()), PrecisionMilliseconds),
+		withPrecision(NewTimestamp(2020, 8, 30, 15, 33, 15, 0, TZAtUTC()), PrecisionMilliseconds),
+		NewTimestamp(2020, 8, 30, 15, 33, 16, 0, TZAtUTC()),
+		withPrecision(NewTimestamp(2020, 8, 30, 15, 33, 17, 0, TZAtUTC()), PrecisionNanoseconds),
+		ZeroTimestamp(),
+	})
+}
+
+func TestTimestampColumnSize(t *testing.T) {
+	times := make([]Time, 1000)
+	individualSize := 0
+	for i := range times {
+		times[i] = NewTimestamp(2020, 8, 30, 15, 33, 0, i*1000000, TZAtAreaLocation("America/New_York"))
+		individualSize += times[i].EncodedSize()
+	}
+	encoded := assertColumnRoundTrip(t, times)
+	if len(encoded)*4 > individualSize {
+		t.Errorf("Expected column of %v bytes to be a lot smaller than %v individually encoded bytes", len(encoded), individualSize)
+	}
+}
+
+func TestTimestampColumnErrors(t *testing.T) {
+	invalidColumns := [][]Time{
+		{NewDate(2020, 1, 1)},
+		{NewTimestamp(2020, 13, 1, 0, 0, 0, 0, TZAtUTC())},
+		{NewTimestamp(1<<40, 1, 1, 0, 0, 0, 0, TZAtUTC())},
+	}
+	for _, times := range invalidColumns {
+		if encoded, err := EncodeTimestampColumn(times); err == nil {
+			t.Errorf("Expected encoding column %v to fail but got %v", times, describe.D(encoded))
+		}
+	}
+
+	encoded, err := EncodeTimestampColumn([]Time{
+		NewTimestamp(2020, 8, 30, 15, 33, 14, 0, TZAtAreaLocation("America/New_York")),
+		NewTimestamp(2020, 8, 30, 15, 33, 15, 0, TZAtUTC()),
+	})
+	if err != nil {
+		t.Fatalf("Error encoding column: %v", err)
+	}
+	for length := 0; length < len(encoded); length++ {
+		if _, _, err := DecodeTimestampColumn(encoded[:length]); !errors.Is(err, ErrorIncomplete) {
+			t.Errorf("Expected decoding %v to fail with %v but got %v", describe.D(encoded[:length]), ErrorIncomplete, err)
+		}
+	}
+	if _, _, err := DecodeTimestampColumn([]byte{0x01, 0x04, 0x00, 0x00, 0x00}); err == nil {
+		t.Errorf("Expected an invalid magnitude to fail")
+	}
+	if _, _, err := DecodeTimestampColumn([]byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00}); err == nil {
+		t.Errorf("Expected an overlong time zone run to fail")
+	}
+	if _, _, err := DecodeTimestampColumn([]byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00}); err == nil {
+		t.Errorf("Expected an overlong precision run to fail")
+	}
+	if _, _, err := DecodeTimestampColumn([]byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x05}); err == nil {
+		t.Errorf("Expected an invalid precision to fail")
+	}
+}
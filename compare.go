@@ -0,0 +1,530 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"fmt"
+	"sort"
+	gotime "time"
+)
+
+// Compare two encoded timestamps by the instant they represent, returning -1
+// if a is earlier than b, 1 if a is later, and 0 if they're the same instant.
+//
+// UTC timestamps are compared directly from their encoded fields. If either
+// timestamp has a time zone, both are decoded and compared using Time.Compare,
+// which is slower. The result (or error) is always the same as Time.Compare's.
+//
+// Note: Zero values are ordered before all other timestamps.
+// Note: A leap second (second 60) comes after second 59 and before the next
+//       minute.
+func CompareEncodedTimestamps(a, b []byte) (int, error) {
+	aKey, aHasTimezone, err := decodeUTCInstantKey(a)
+	if err != nil {
+		return 0, err
+	}
+	bKey, bHasTimezone, err := decodeUTCInstantKey(b)
+	if err != nil {
+		return 0, err
+	}
+	if !aHasTimezone && !bHasTimezone {
+		if err = aKey.validate(); err != nil {
+			return 0, err
+		}
+		if err = bKey.validate(); err != nil {
+			return 0, err
+		}
+		return aKey.compare(&bKey), nil
+	}
+
+	aTime, _, err := DecodeTimestampFromBytes(a)
+	if err != nil {
+		return 0, err
+	}
+	bTime, _, err := DecodeTimestampFromBytes(b)
+	if err != nil {
+		return 0, err
+	}
+	return aTime.Compare(bTime)
+}
+
+// Compare two dates or two timestamps, returning -1 if this is earlier than
+// that, 1 if it's later, and 0 if they're the same.
+//
+// Dates are compared by calendar date. Timestamps are compared by the instant
+// they represent, so 12:00+01:00 and 11:00Z are the same. Area/location time
+// zones are resolved using the time zone database, and latitude/longitude time
+// zones using the resolver set by SetLatLongResolver. Local timestamps have no
+// known UTC offset, so they can only be compared to other local timestamps (by
+// their local clocks).
+//
+// Note: Zero values are ordered before all other values.
+// Note: A leap second (second 60) comes after second 59 and before the next
+//       minute.
+// Note: Area/location timestamps whose local time doesn't exist or is
+//       ambiguous (due to daylight savings) can't be compared. See Add.
+func (this *Time) Compare(that Time) (result int, err error) {
+	if err = this.checkComparableTo(&that); err != nil {
+		return
+	}
+	var thisKey, thatKey instantKey
+	if thisKey, err = this.orderingKey(); err != nil {
+		return
+	}
+	if thatKey, err = that.orderingKey(); err != nil {
+		return
+	}
+	return thisKey.compare(&thatKey), nil
+}
+
+// Check if this is earlier than that. See Compare.
+func (this *Time) Before(that Time) (isBefore bool, err error) {
+	result, err := this.Compare(that)
+	return result < 0, err
+}
+
+// Check if this is later than that. See Compare.
+func (this *Time) After(that Time) (isAfter bool, err error) {
+	result, err := this.Compare(that)
+	return result > 0, err
+}
+
+// Check if this and that represent the same instant (or the same calendar
+// date), even if their time zones differ. Use IsEquivalentTo to compare the
+// fields themselves. See Compare.
+func (this *Time) SameInstant(that Time) (isSame bool, err error) {
+	result, err := this.Compare(that)
+	return result == 0 && err == nil, err
+}
+
+// Sort dates or timestamps from earliest to latest, as they'd be ordered by
+// Compare. The sort is stable, so values representing the same instant keep
+// their original order.
+//
+// If any value can't be compared to the others, an error is returned and the
+// slice is left as-is.
+func SortByInstant(times []Time) error {
+	sorter := instantSorter{
+		times: times,
+		keys:  make([]instantKey, len(times)),
+	}
+	reference := 0
+	for i := range times {
+		if times[reference].IsZeroValue() {
+			reference = i
+		}
+		if err := times[reference].checkComparableTo(&times[i]); err != nil {
+			return err
+		}
+		key, err := times[i].orderingKey()
+		if err != nil {
+			return err
+		}
+		sorter.keys[i] = key
+	}
+	sort.Stable(&sorter)
+	return nil
+}
+
+// Convert a timestamp to the same instant in another time zone, recomputing
+// its date and time fields. The subseconds and precision are kept.
+//
+// Area/location targets use the time zone database, and latitude/longitude
+// targets use the resolver set by SetLatLongResolver (without one, the error
+// wraps ErrorUnresolvableTimezone). Local timestamps have no known UTC offset,
+// so they can only be converted to local time (which changes nothing), and
+// nothing can be converted to local time.
+//
+// Note: A leap second (second 60) stays a leap second when the target UTC
+//       offset is a whole number of minutes.
+// Note: Area/location timestamps whose local time doesn't exist or is
+//       ambiguous (due to daylight savings) can't be converted. See Add.
+func (this *Time) In(tz Timezone) (result Time, err error) {
+	if err = this.checkArithmetic(); err != nil {
+		return
+	}
+	if err = tz.Validate(); err != nil {
+		return
+	}
+	if this.Timezone.Type == TimezoneTypeLocal && tz.Type == TimezoneTypeLocal {
+		return *this, nil
+	}
+
+	instant, err := this.resolveInstant()
+	if err != nil {
+		return
+	}
+	switch tz.Type {
+	case TimezoneTypeUTC:
+	case TimezoneTypeUTCOffset:
+		instant = instant.Add(gotime.Duration(tz.MinutesOffsetFromUTC) * gotime.Minute)
+	case TimezoneTypeAreaLocation, TimezoneTypeLatitudeLongitude:
+		var location *gotime.Location
+		if location, err = tz.goLocation(); err != nil {
+			return
+		}
+		instant = instant.In(location)
+	default:
+		err = fmt.Errorf("%v: %w", tz.String(), ErrorUnresolvableTimezone)
+		return
+	}
+
+	result = *this
+	result.Timezone = tz
+	result.setResolvedWallClock(instant, this.Second == 60)
+	return
+}
+
+// Convert a timestamp to the same instant in UTC. See In.
+func (this *Time) ToUTC() (Time, error) {
+	return this.In(timezoneUTC)
+}
+
+// Replace a timestamp's time zone with the UTC offset in effect at the instant
+// it represents, keeping its date and time fields. For example,
+// 2020-07-01/12:00:00/America/Los_Angeles becomes 2020-07-01/12:00:00-0700.
+//
+// UTC and UTC offset timestamps are returned as-is. Local timestamps have no
+// known UTC offset, so they return an error wrapping ErrorUnresolvableTimezone.
+//
+// Note: Some historical offsets (such as local mean time before time zones
+//       were standardized) aren't a whole number of minutes, and can't be
+//       represented.
+func (this *Time) ToFixedOffset() (result Time, err error) {
+	if err = this.checkArithmetic(); err != nil {
+		return
+	}
+	result = *this
+	switch this.Timezone.Type {
+	case TimezoneTypeUTC, TimezoneTypeUTCOffset:
+		return
+	}
+
+	instant, err := this.resolveInstant()
+	if err != nil {
+		return
+	}
+	location, _ := this.Timezone.goLocation()
+	_, offsetSeconds := instant.In(location).Zone()
+	if offsetSeconds%60 != 0 {
+		err = fmt.Errorf("%v: UTC offset of %v seconds is not a whole number of minutes", this, offsetSeconds)
+		return
+	}
+	result.Timezone = TZWithMiutesOffsetFromUTC(offsetSeconds / 60)
+	return
+}
+
+// =============================================================================
+
+type instantSorter struct {
+	times []Time
+	keys  []instantKey
+}
+
+func (this *instantSorter) Len() int {
+	return len(this.times)
+}
+
+func (this *instantSorter) Less(i, j int) bool {
+	return this.keys[i].compare(&this.keys[j]) < 0
+}
+
+func (this *instantSorter) Swap(i, j int) {
+	this.times[i], this.times[j] = this.times[j], this.times[i]
+	this.keys[i], this.keys[j] = this.keys[j], this.keys[i]
+}
+
+func (this *Time) checkComparableTo(that *Time) error {
+	if this.Type != that.Type {
+		return fmt.Errorf("Cannot compare %v to %v: Only values of the same time type can be compared", this, that)
+	}
+	if this.Type != TimeTypeTimestamp || this.IsZeroValue() || that.IsZeroValue() {
+		return nil
+	}
+	if (this.Timezone.Type == TimezoneTypeLocal) != (that.Timezone.Type == TimezoneTypeLocal) {
+		return fmt.Errorf("Cannot compare %v to %v: %w", this, that, ErrorUnresolvableTimezone)
+	}
+	return nil
+}
+
+// Get a key that orders dates by calendar date, and timestamps by instant
+// (except for local timestamps, which are ordered by their local clocks).
+func (this *Time) orderingKey() (key instantKey, err error) {
+	if this.Type != TimeTypeDate && this.Type != TimeTypeTimestamp {
+		err = fmt.Errorf("%v: Only dates and timestamps can be ordered", this)
+		return
+	}
+	if this.IsZeroValue() {
+		return
+	}
+	if err = this.Validate(); err != nil {
+		return
+	}
+
+	switch {
+	case this.Type == TimeTypeDate:
+		return this.fieldsInstantKey(), nil
+	case this.Timezone.Type == TimezoneTypeUTC, this.Timezone.Type == TimezoneTypeLocal:
+		return this.fieldsInstantKey(), nil
+	}
+
+	instant, err := this.resolveInstant()
+	if err != nil {
+		return
+	}
+	utc := *this
+	utc.Timezone = timezoneUTC
+	utc.setResolvedWallClock(instant, this.Second == 60)
+	return utc.fieldsInstantKey(), nil
+}
+
+func (this *Time) fieldsInstantKey() instantKey {
+	return instantKey{
+		isNonZero:  true,
+		year:       this.Year,
+		fields:     packColumnFields(this),
+		nanosecond: this.Nanosecond,
+	}
+}
+
+// A point in time as UTC fields, which can be compared without needing to
+// worry about time zones.
+type instantKey struct {
+	isNonZero bool
+	year      int
+	// Month, day, hour, minute and second, packed as in packColumnFields
+	fields     int64
+	nanosecond uint32
+}
+
+func (this *instantKey) compare(that *instantKey) int {
+	switch {
+	case this.isNonZero != that.isNonZero:
+		return compareBools(this.isNonZero, that.isNonZero)
+	case this.year != that.year:
+		return compareInts(int64(this.year), int64(that.year))
+	case this.fields != that.fields:
+		return compareInts(this.fields, that.fields)
+	default:
+		return compareInts(int64(this.nanosecond), int64(that.nanosecond))
+	}
+}
+
+// Check the fields of a key decoded straight from an encoded UTC timestamp,
+// giving the same error that Validate would for the decoded timestamp.
+func (this *instantKey) validate() error {
+	if !this.isNonZero {
+		return nil
+	}
+	time := Time{
+		Type:       TimeTypeTimestamp,
+		Year:       this.year,
+		Nanosecond: this.nanosecond,
+		Timezone:   timezoneUTC,
+	}
+	unpackColumnFields(&time, this.fields)
+	return time.Validate()
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// Returned (wrapped) when a timestamp's local time falls into a gap in its
+// time zone, such as when the clocks go forward for daylight savings.
+var ErrorNonexistentLocalTime = fmt.Errorf("Local time does not exist in its time zone")
+
+// Returned (wrapped) when a timestamp's local time occurs twice in its time
+// zone, such as when the clocks go back for daylight savings.
+var ErrorAmbiguousLocalTime = fmt.Errorf("Local time occurs more than once in its time zone")
+
+// Returned (wrapped) when a time zone can't be tied to a UTC offset, such as
+// the local time zone (which depends on where the value is read) or a
+// latitude/longitude when no resolver has been set.
+var ErrorUnresolvableTimezone = fmt.Errorf("Time zone cannot be resolved to a UTC offset")
+
+// Get the instant that a timestamp represents, using the time zone database
+// for area/location time zones.
+//
+// Go time doesn't track leap seconds, so second 60 is treated as second 59.
+func (this *Time) resolveInstant() (instant gotime.Time, err error) {
+	wallClock := this.wallClock()
+	switch this.Timezone.Type {
+	case TimezoneTypeUTC:
+		return wallClock, nil
+	case TimezoneTypeUTCOffset:
+		return wallClock.Add(-gotime.Duration(this.Timezone.MinutesOffsetFromUTC) * gotime.Minute), nil
+	case TimezoneTypeAreaLocation, TimezoneTypeLatitudeLongitude:
+		var location *gotime.Location
+		if location, err = this.Timezone.goLocation(); err != nil {
+			return
+		}
+		if instant, err = resolveWallClock(wallClock, location); err != nil {
+			err = fmt.Errorf("%v: %w", this, err)
+		}
+		return
+	default:
+		err = fmt.Errorf("%v: %w", this.Timezone.String(), ErrorUnresolvableTimezone)
+		return
+	}
+}
+
+// Get a timestamp's date and time fields as a go time in UTC, without
+// applying its time zone.
+func (this *Time) wallClock() gotime.Time {
+	second := int(this.Second)
+	if second > 59 {
+		second = 59
+	}
+	return gotime.Date(astronomicalYear(this.Year), gotime.Month(this.Month), int(this.Day), int(this.Hour),
+		int(this.Minute), second, int(this.Nanosecond), gotime.UTC)
+}
+
+// Set a timestamp's date and time fields from a go time's wall clock, raising
+// the declared precision if the new nanoseconds need more.
+func (this *Time) setWallClock(wallClock gotime.Time) {
+	this.Year = historicalYear(wallClock.Year())
+	this.Month = uint8(wallClock.Month())
+	this.Day = uint8(wallClock.Day())
+	this.Hour = uint8(wallClock.Hour())
+	this.Minute = uint8(wallClock.Minute())
+	this.Second = uint8(wallClock.Second())
+	this.Nanosecond = uint32(wallClock.Nanosecond())
+	this.fitPrecision()
+}
+
+// Set a timestamp's fields from a wall clock that came from resolveInstant.
+// resolveInstant treats a leap second as second 59, so put it back.
+func (this *Time) setResolvedWallClock(wallClock gotime.Time, isLeapSecond bool) {
+	this.setWallClock(wallClock)
+	if isLeapSecond && this.Second == 59 {
+		this.Second = 60
+	}
+}
+
+// Raise the declared precision (if any) to hold the nanoseconds.
+func (this *Time) fitPrecision() {
+	if this.Precision != PrecisionUnspecified {
+		if magnitude := getSubsecondMagnitude(int(this.Nanosecond)); magnitude > this.subsecondMagnitude() {
+			this.Precision = decodePrecision(magnitude)
+		}
+	}
+}
+
+// Compact time has no year 0 (1 BC is year -1), but go time does.
+func astronomicalYear(year int) int {
+	if year < 0 {
+		return year + 1
+	}
+	return year
+}
+
+func historicalYear(year int) int {
+	if year <= 0 {
+		return year - 1
+	}
+	return year
+}
+
+// Find the one instant at which a location's clocks show wallClock. Go time
+// silently picks an instant for local times that don't exist or occur twice,
+// so instead every offset in effect around that time is tried.
+func resolveWallClock(wallClock gotime.Time, location *gotime.Location) (instant gotime.Time, err error) {
+	matchCount := 0
+	for _, probe := range [...]gotime.Duration{-48 * gotime.Hour, 0, 48 * gotime.Hour} {
+		_, offsetSeconds := wallClock.Add(probe).In(location).Zone()
+		candidate := wallClock.Add(-gotime.Duration(offsetSeconds) * gotime.Second)
+		local := candidate.In(location)
+		if !gotime.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(),
+			local.Second(), local.Nanosecond(), gotime.UTC).Equal(wallClock) {
+			continue
+		}
+		if matchCount == 0 || !candidate.Equal(instant) {
+			instant = candidate
+			matchCount++
+		}
+	}
+	switch matchCount {
+	case 0:
+		err = ErrorNonexistentLocalTime
+	case 1:
+		instant = instant.UTC()
+	default:
+		err = ErrorAmbiguousLocalTime
+	}
+	return
+}
+
+// Get the UTC instant of an encoded timestamp straight from its encoded
+// fields. If the timestamp has a time zone, hasTimezone will be true and the
+// key will be for the local time.
+func decodeUTCInstantKey(src []byte) (key instantKey, hasTimezone bool, err error) {
+	if err = requireBytes(src, 1, DecodeFieldHeader); err != nil {
+		return
+	}
+	magnitude := decodeMagnitude(src[0])
+	baseByteCount := baseByteCountsTimestamp[magnitude]
+	if err = requireBytes(src, baseByteCount, DecodeFieldMagnitude); err != nil {
+		return
+	}
+
+	accumulator := decodeLE(src, baseByteCount)
+	hasTimezone = accumulator&1 == 1
+	accumulator >>= sizeUtc + sizeMagnitude
+	sizeSubseconds := sizeSubsecond * magnitude
+	key.nanosecond = uint32(accumulator&bitMask(sizeSubseconds)) * uint32(subsecMultipliers[magnitude])
+	accumulator >>= uint(sizeSubseconds)
+	key.fields = int64(accumulator & maskColumnFields)
+	accumulator >>= columnFieldsBitCount
+
+	var encodedYear uint32
+	encodedYear, _, err = decodeYearGroupsFromBytes(src, accumulator, yearLowBitCountsTimestamp[magnitude], baseByteCount)
+	if err != nil {
+		return
+	}
+	key.year = decodeYear(encodedYear)
+
+	monthAndDay := key.fields >> (sizeHour + sizeMinute + sizeSecond)
+	if !hasTimezone && key.year == yearBias && monthAndDay == 0 {
+		key = instantKey{}
+		return
+	}
+	key.isNonZero = true
+	return
+}
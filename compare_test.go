@@ -0,0 +1,402 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	gotime "time"
+)
+
+func assertCompareEncoded(t *testing.T, a, b Time, expected int) {
+	aEncoded := encodeAll(t, a)
+	bEncoded := encodeAll(t, b)
+	actual, err := CompareEncodedTimestamps(aEncoded, bEncoded)
+	if err != nil {
+		t.Errorf("Error comparing %v to %v: %v", a, b, err)
+		return
+	}
+	if actual != expected {
+		t.Errorf("Expected comparing %v to %v to give %v but got %v", a, b, expected, actual)
+	}
+	if reversed, _ := CompareEncodedTimestamps(bEncoded, aEncoded); reversed != -expected {
+		t.Errorf("Expected comparing %v to %v to give %v but got %v", b, a, -expected, reversed)
+	}
+}
+
+func TestCompareEncodedTimestamps(t *testing.T) {
+	utcOffsetZero := Timezone{Type: TimezoneTypeUTCOffset}
+
+	assertCompareEncoded(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), 0)
+	assertCompareEncoded(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), NewTimestamp(2020, 1, 1, 0, 0, 0, 1, TZAtUTC()), -1)
+	assertCompareEncoded(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 1000000, TZAtUTC()), NewTimestamp(2020, 1, 1, 0, 0, 0, 999999, TZAtUTC()), 1)
+	assertCompareEncoded(t, NewTimestamp(-1, 12, 31, 23, 59, 59, 0, TZAtUTC()), NewTimestamp(1, 1, 1, 0, 0, 0, 0, TZAtUTC()), -1)
+	assertCompareEncoded(t, NewTimestamp(2016, 12, 31, 23, 59, 60, 0, TZAtUTC()), NewTimestamp(2016, 12, 31, 23, 59, 59, 999999999, TZAtUTC()), 1)
+	assertCompareEncoded(t, NewTimestamp(2016, 12, 31, 23, 59, 60, 0, TZAtUTC()), NewTimestamp(2017, 1, 1, 0, 0, 0, 0, TZAtUTC()), -1)
+	assertCompareEncoded(t, ZeroTimestamp(), NewTimestamp(-50000, 1, 1, 0, 0, 0, 0, TZAtUTC()), -1)
+	assertCompareEncoded(t, ZeroTimestamp(), ZeroTimestamp(), 0)
+
+	assertCompareEncoded(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, utcOffsetZero), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), 0)
+	assertCompareEncoded(t, NewTimestamp(2020, 1, 1, 9, 0, 0, 0, TZAtAreaLocation("Asia/Tokyo")), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), 0)
+	assertCompareEncoded(t, NewTimestamp(2020, 1, 1, 9, 0, 0, 1, TZAtAreaLocation("Asia/Tokyo")), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), 1)
+	assertCompareEncoded(t, NewTimestamp(2020, 7, 1, 0, 0, 0, 0, TZAtAreaLocation("America/New_York")), NewTimestamp(2020, 7, 1, 0, 0, 0, 0, TZWithMiutesOffsetFromUTC(-240)), 0)
+	assertCompareEncoded(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtAreaLocation("America/New_York")), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZWithMiutesOffsetFromUTC(-240)), 1)
+	assertCompareEncoded(t, NewTimestamp(2016, 12, 31, 20, 59, 60, 0, TZWithMiutesOffsetFromUTC(-180)), NewTimestamp(2016, 12, 31, 23, 59, 60, 0, TZAtUTC()), 0)
+	assertCompareEncoded(t, ZeroTimestamp(), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, utcOffsetZero), -1)
+	assertCompareEncoded(t, NewTimestamp(1, 1, 1, 0, 30, 0, 0, TZWithMiutesOffsetFromUTC(60)), NewTimestamp(-1, 12, 31, 23, 30, 0, 0, TZAtUTC()), 0)
+	assertCompareEncoded(t, NewTimestamp(2020, 1, 1, 10, 0, 0, 0, TZLocal()), NewTimestamp(2020, 1, 1, 9, 0, 0, 0, TZLocal()), 1)
+
+	utc := encodeAll(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()))
+	for _, value := range []Time{
+		NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtLatLong(100, 100)),
+		NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZLocal()),
+		NewTimestamp(2020, 11, 1, 1, 30, 0, 0, TZAtAreaLocation("America/New_York")),
+		NewTimestamp(2020, 3, 8, 2, 30, 0, 0, TZAtAreaLocation("America/New_York")),
+	} {
+		if _, err := CompareEncodedTimestamps(encodeAll(t, value), utc); err == nil {
+			t.Errorf("Expected comparing %v to a UTC timestamp to fail", value)
+		}
+	}
+	if _, err := CompareEncodedTimestamps(utc, utc[:len(utc)-1]); err == nil {
+		t.Errorf("Expected comparing a truncated timestamp to fail")
+	}
+}
+
+func randomTimestamp(random *rand.Rand) Time {
+	timezones := []Timezone{
+		TZAtUTC(),
+		TZWithMiutesOffsetFromUTC(random.Intn(2879) - 1439),
+		{Type: TimezoneTypeUTCOffset},
+		TZAtAreaLocation("America/New_York"),
+		TZAtAreaLocation("Asia/Kolkata"),
+	}
+	nanosecond := []int{0, random.Intn(1000) * 1000000, random.Intn(1000000) * 1000, random.Intn(1000000000)}[random.Intn(4)]
+	if random.Intn(50) == 0 {
+		return ZeroTimestamp()
+	}
+	return NewTimestamp(1990+random.Intn(40), 1+random.Intn(12), 1+random.Intn(28),
+		random.Intn(24), random.Intn(60), random.Intn(61), nanosecond, timezones[random.Intn(len(timezones))])
+}
+
+// Encode a timestamp without validating it first.
+func encodeUnchecked(time Time) []byte {
+	buffer := make([]byte, time.EncodedSize())
+	return buffer[:time.encodeTimestamp(buffer)]
+}
+
+func assertCompareEncodedAgrees(t *testing.T, aEncoded, bEncoded []byte) {
+	a, _, err := DecodeTimestampFromBytes(aEncoded)
+	if err != nil {
+		t.Errorf("Error decoding %v: %v", aEncoded, err)
+		return
+	}
+	b, _, err := DecodeTimestampFromBytes(bEncoded)
+	if err != nil {
+		t.Errorf("Error decoding %v: %v", bEncoded, err)
+		return
+	}
+	expected, expectedErr := a.Compare(b)
+	actual, err := CompareEncodedTimestamps(aEncoded, bEncoded)
+	if (err != nil) != (expectedErr != nil) || actual != expected {
+		t.Errorf("Expected comparing encoded %v to %v to give %v (%v) but got %v (%v)", a, b, expected, expectedErr, actual, err)
+	}
+	if err != nil && expectedErr != nil && err.Error() != expectedErr.Error() {
+		t.Errorf("Expected comparing encoded %v to %v to fail with %v but got %v", a, b, expectedErr, err)
+	}
+}
+
+func TestCompareEncodedTimestampsAgreesWithTime(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		a := randomTimestamp(random)
+		b := randomTimestamp(random)
+		if random.Intn(5) == 0 {
+			b.Second = a.Second
+			b.Minute = a.Minute
+		}
+		for _, time := range []*Time{&a, &b} {
+			if time.IsZeroValue() {
+				continue
+			}
+			switch random.Intn(10) {
+			case 0:
+				time.Year = -time.Year
+			case 1:
+				time.Timezone = TZLocal()
+			}
+		}
+		assertCompareEncodedAgrees(t, encodeAll(t, a), encodeAll(t, b))
+	}
+
+	utc := TZAtUTC()
+	values := []Time{
+		ZeroTimestamp(),
+		NewTimestamp(2016, 12, 31, 23, 59, 59, 999999999, utc),
+		NewTimestamp(2016, 12, 31, 23, 59, 60, 0, utc),
+		NewTimestamp(2016, 12, 31, 23, 59, 60, 500000000, utc),
+		NewTimestamp(2017, 1, 1, 0, 0, 0, 0, utc),
+		NewTimestamp(2016, 12, 31, 20, 59, 60, 0, TZWithMiutesOffsetFromUTC(-180)),
+		NewTimestamp(-1, 12, 31, 23, 59, 59, 0, utc),
+		NewTimestamp(-1, 12, 31, 23, 59, 60, 0, utc),
+		NewTimestamp(-1, 12, 31, 23, 0, 0, 0, TZWithMiutesOffsetFromUTC(-60)),
+		NewTimestamp(-2, 1, 1, 0, 0, 0, 0, utc),
+		NewTimestamp(-50000, 6, 15, 12, 0, 0, 0, utc),
+		NewTimestamp(1, 1, 1, 0, 0, 0, 0, utc),
+	}
+	encoded := make([][]byte, 0, len(values))
+	for _, value := range values {
+		encoded = append(encoded, encodeAll(t, value))
+	}
+	for _, value := range []Time{
+		NewTimestamp(2020, 13, 1, 0, 0, 0, 0, utc),
+		NewTimestamp(2020, 0, 1, 0, 0, 0, 0, utc),
+		NewTimestamp(2020, 1, 0, 0, 0, 0, 0, utc),
+		NewTimestamp(2020, 2, 30, 0, 0, 0, 0, utc),
+		NewTimestamp(2020, 1, 1, 24, 0, 0, 0, utc),
+		NewTimestamp(2020, 1, 1, 0, 60, 0, 0, utc),
+		NewTimestamp(2020, 1, 1, 0, 0, 61, 0, utc),
+		NewTimestamp(2020, 1, 1, 0, 0, 0, 1000000000, utc),
+		NewTimestamp(0, 1, 1, 0, 0, 0, 0, utc),
+		NewTimestamp(2020, 13, 1, 0, 0, 0, 0, TZWithMiutesOffsetFromUTC(60)),
+	} {
+		encoded = append(encoded, encodeUnchecked(value))
+	}
+	for _, a := range encoded {
+		for _, b := range encoded {
+			assertCompareEncodedAgrees(t, a, b)
+		}
+	}
+}
+
+func assertCompare(t *testing.T, a, b Time, expected int) {
+	actual, err := a.Compare(b)
+	if err != nil {
+		t.Errorf("Error comparing %v to %v: %v", a, b, err)
+		return
+	}
+	if actual != expected {
+		t.Errorf("Expected comparing %v to %v to give %v but got %v", a, b, expected, actual)
+	}
+	if reversed, _ := b.Compare(a); reversed != -expected {
+		t.Errorf("Expected comparing %v to %v to give %v but got %v", b, a, -expected, reversed)
+	}
+	if isBefore, _ := a.Before(b); isBefore != (expected < 0) {
+		t.Errorf("Expected %v before %v to be %v", a, b, expected < 0)
+	}
+	if isAfter, _ := a.After(b); isAfter != (expected > 0) {
+		t.Errorf("Expected %v after %v to be %v", a, b, expected > 0)
+	}
+	if isSame, _ := a.SameInstant(b); isSame != (expected == 0) {
+		t.Errorf("Expected %v same instant as %v to be %v", a, b, expected == 0)
+	}
+}
+
+func assertCompareFails(t *testing.T, a, b Time, expectedErr error) {
+	if _, err := a.Compare(b); err == nil || expectedErr != nil && !errors.Is(err, expectedErr) {
+		t.Errorf("Expected comparing %v to %v to fail with %v but got %v", a, b, expectedErr, err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	assertCompare(t, NewTimestamp(2020, 1, 1, 12, 0, 0, 0, TZWithMiutesOffsetFromUTC(60)), NewTimestamp(2020, 1, 1, 11, 0, 0, 0, TZAtUTC()), 0)
+	assertCompare(t, NewTimestamp(2020, 1, 1, 12, 0, 0, 0, TZWithMiutesOffsetFromUTC(60)), NewTimestamp(2020, 1, 1, 11, 30, 0, 0, TZAtUTC()), -1)
+	assertCompare(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZWithMiutesOffsetFromUTC(-60)), NewTimestamp(2019, 12, 31, 23, 59, 59, 999999999, TZAtUTC()), 1)
+	assertCompare(t, NewTimestamp(2020, 1, 1, 9, 0, 0, 0, TZAtAreaLocation("Asia/Tokyo")), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), 0)
+	assertCompare(t, NewTimestamp(2020, 7, 1, 0, 0, 0, 0, TZAtAreaLocation("America/New_York")), NewTimestamp(2020, 7, 1, 0, 0, 0, 0, TZWithMiutesOffsetFromUTC(-240)), 0)
+	assertCompare(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtAreaLocation("America/New_York")), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZWithMiutesOffsetFromUTC(-240)), 1)
+	assertCompare(t, NewTimestamp(-1, 12, 31, 23, 0, 0, 0, TZWithMiutesOffsetFromUTC(-60)), NewTimestamp(1, 1, 1, 0, 0, 0, 0, TZAtUTC()), 0)
+	assertCompare(t, NewTimestamp(2016, 12, 31, 20, 59, 60, 0, TZWithMiutesOffsetFromUTC(-180)), NewTimestamp(2016, 12, 31, 23, 59, 59, 500000000, TZAtUTC()), 1)
+	assertCompare(t, NewTimestamp(2016, 12, 31, 20, 59, 60, 0, TZWithMiutesOffsetFromUTC(-180)), NewTimestamp(2017, 1, 1, 0, 0, 0, 0, TZAtUTC()), -1)
+	assertCompare(t, NewTimestamp(2020, 1, 1, 10, 0, 0, 0, TZLocal()), NewTimestamp(2020, 1, 1, 9, 0, 0, 0, TZLocal()), 1)
+	assertCompare(t, ZeroTimestamp(), NewTimestamp(-50000, 1, 1, 0, 0, 0, 0, TZAtUTC()), -1)
+	assertCompare(t, ZeroTimestamp(), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZLocal()), -1)
+	assertCompare(t, ZeroTimestamp(), ZeroTimestamp(), 0)
+
+	assertCompare(t, NewDate(2020, 1, 1), NewDate(2020, 1, 1), 0)
+	assertCompare(t, NewDate(2020, 1, 31), NewDate(2020, 2, 1), -1)
+	assertCompare(t, NewDate(1, 1, 1), NewDate(-1, 12, 31), 1)
+	assertCompare(t, ZeroDate(), NewDate(-50000, 1, 1), -1)
+
+	assertCompareFails(t, NewDate(2020, 1, 1), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), nil)
+	assertCompareFails(t, NewTime(10, 0, 0, 0, TZAtUTC()), NewTime(11, 0, 0, 0, TZAtUTC()), nil)
+	assertCompareFails(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZLocal()), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), ErrorUnresolvableTimezone)
+	assertCompareFails(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtLatLong(100, 100)), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), ErrorUnresolvableTimezone)
+	assertCompareFails(t, NewTimestamp(2020, 3, 8, 2, 30, 0, 0, TZAtAreaLocation("America/New_York")), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), ErrorNonexistentLocalTime)
+	assertCompareFails(t, NewTimestamp(2020, 11, 1, 1, 30, 0, 0, TZAtAreaLocation("America/New_York")), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), ErrorAmbiguousLocalTime)
+	assertCompareFails(t, NewTimestamp(2020, 2, 30, 0, 0, 0, 0, TZAtUTC()), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), nil)
+}
+
+func TestLatLongResolver(t *testing.T) {
+	defer SetLatLongResolver(nil)
+	tokyo, err := gotime.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetLatLongResolver(func(latitudeHundredths, longitudeHundredths int) (*gotime.Location, error) {
+		if latitudeHundredths == 3568 && longitudeHundredths == 13976 {
+			return tokyo, nil
+		}
+		return nil, fmt.Errorf("Unknown location")
+	})
+
+	assertCompare(t, NewTimestamp(2020, 1, 1, 9, 0, 0, 0, TZAtLatLong(3568, 13976)), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), 0)
+	assertCompareFails(t, NewTimestamp(2020, 1, 1, 9, 0, 0, 0, TZAtLatLong(100, 100)), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), nil)
+
+	latLong := NewTimestamp(2020, 1, 1, 9, 0, 0, 0, TZAtLatLong(3568, 13976))
+	if goTime, err := latLong.AsGoTime(); err != nil || !goTime.Equal(gotime.Date(2020, 1, 1, 0, 0, 0, 0, gotime.UTC)) {
+		t.Errorf("Expected %v to convert to 2020-01-01 00:00 UTC but got %v (%v)", latLong, goTime, err)
+	}
+
+	SetLatLongResolver(nil)
+	assertCompareFails(t, NewTimestamp(2020, 1, 1, 9, 0, 0, 0, TZAtLatLong(3568, 13976)), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), ErrorUnresolvableTimezone)
+}
+
+func TestSortByInstant(t *testing.T) {
+	times := []Time{
+		NewTimestamp(2020, 1, 1, 12, 0, 0, 0, TZWithMiutesOffsetFromUTC(60)),
+		NewTimestamp(2020, 1, 1, 10, 0, 0, 0, TZAtUTC()),
+		ZeroTimestamp(),
+		NewTimestamp(2020, 1, 1, 11, 0, 0, 0, TZAtUTC()),
+		NewTimestamp(2020, 1, 1, 19, 30, 0, 0, TZAtAreaLocation("Asia/Tokyo")),
+	}
+	expected := []Time{times[2], times[1], times[4], times[0], times[3]}
+	if err := SortByInstant(times); err != nil {
+		t.Fatal(err)
+	}
+	for i := range expected {
+		if times[i] != expected[i] {
+			t.Errorf("Expected index %v to be %v but got %v", i, expected[i], times[i])
+		}
+	}
+
+	dates := []Time{NewDate(2020, 3, 1), NewDate(-1, 1, 1), NewDate(2020, 2, 29)}
+	if err := SortByInstant(dates); err != nil {
+		t.Fatal(err)
+	}
+	if dates[0].Year != -1 || dates[1].Month != 2 || dates[2].Month != 3 {
+		t.Errorf("Expected dates to be sorted but got %v", dates)
+	}
+
+	mixed := []Time{ZeroTimestamp(), NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZLocal()), NewTimestamp(2019, 1, 1, 0, 0, 0, 0, TZAtUTC())}
+	if err := SortByInstant(mixed); !errors.Is(err, ErrorUnresolvableTimezone) {
+		t.Errorf("Expected sorting local and UTC timestamps to fail but got %v", err)
+	}
+	if mixed[2].Year != 2019 {
+		t.Errorf("Expected a failed sort to leave the slice as-is")
+	}
+	if err := SortByInstant([]Time{NewDate(2020, 1, 1), ZeroTimestamp()}); err == nil {
+		t.Errorf("Expected sorting dates and timestamps to fail")
+	}
+}
+
+func assertIn(t *testing.T, time Time, tz Timezone, expected Time) {
+	actual, err := time.In(tz)
+	if err != nil {
+		t.Errorf("Error converting %v to %v: %v", time, tz.String(), err)
+		return
+	}
+	if actual != expected {
+		t.Errorf("Expected converting %v to %v to give %v but got %v", time, tz.String(), expected, actual)
+	}
+}
+
+func assertInFails(t *testing.T, time Time, tz Timezone, expectedErr error) {
+	if _, err := time.In(tz); err == nil || expectedErr != nil && !errors.Is(err, expectedErr) {
+		t.Errorf("Expected converting %v to %v to fail with %v but got %v", time, tz.String(), expectedErr, err)
+	}
+}
+
+func TestIn(t *testing.T) {
+	losAngeles := TZAtAreaLocation("America/Los_Angeles")
+
+	assertIn(t, NewTimestamp(2020, 7, 1, 17, 30, 0, 0, losAngeles), TZAtUTC(), NewTimestamp(2020, 7, 2, 0, 30, 0, 0, TZAtUTC()))
+	assertIn(t, NewTimestamp(2020, 1, 1, 17, 30, 0, 0, losAngeles), TZAtUTC(), NewTimestamp(2020, 1, 2, 1, 30, 0, 0, TZAtUTC()))
+	assertIn(t, NewTimestamp(2020, 7, 1, 17, 30, 0, 0, losAngeles), TZAtAreaLocation("Asia/Tokyo"), NewTimestamp(2020, 7, 2, 9, 30, 0, 0, TZAtAreaLocation("Asia/Tokyo")))
+	assertIn(t, NewTimestamp(2020, 7, 1, 17, 30, 0, 0, losAngeles), TZWithMiutesOffsetFromUTC(330), NewTimestamp(2020, 7, 2, 6, 0, 0, 0, TZWithMiutesOffsetFromUTC(330)))
+	assertIn(t, NewTimestamp(2020, 1, 1, 0, 15, 0, 0, TZWithMiutesOffsetFromUTC(60)), losAngeles, NewTimestamp(2019, 12, 31, 15, 15, 0, 0, losAngeles))
+	assertIn(t, NewTimestamp(1, 1, 1, 0, 30, 0, 0, TZWithMiutesOffsetFromUTC(60)), TZAtUTC(), NewTimestamp(-1, 12, 31, 23, 30, 0, 0, TZAtUTC()))
+	assertIn(t, NewTimestamp(2016, 12, 31, 23, 59, 60, 500000000, TZAtUTC()), TZWithMiutesOffsetFromUTC(-180), NewTimestamp(2016, 12, 31, 20, 59, 60, 500000000, TZWithMiutesOffsetFromUTC(-180)))
+	assertIn(t, NewTimestamp(2020, 1, 1, 10, 0, 0, 0, TZLocal()), TZLocal(), NewTimestamp(2020, 1, 1, 10, 0, 0, 0, TZLocal()))
+
+	precise := withPrecision(NewTimestamp(2020, 7, 1, 17, 30, 0, 120000000, losAngeles), PrecisionMicroseconds)
+	assertIn(t, precise, TZAtUTC(), withPrecision(NewTimestamp(2020, 7, 2, 0, 30, 0, 120000000, TZAtUTC()), PrecisionMicroseconds))
+
+	assertInFails(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), TZAtLatLong(3568, 13976), ErrorUnresolvableTimezone)
+	assertInFails(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), TZLocal(), ErrorUnresolvableTimezone)
+	assertInFails(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZLocal()), TZAtUTC(), ErrorUnresolvableTimezone)
+	assertInFails(t, NewTimestamp(2020, 3, 8, 2, 30, 0, 0, TZAtAreaLocation("America/New_York")), TZAtUTC(), ErrorNonexistentLocalTime)
+	assertInFails(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), TZAtAreaLocation("Nowhere/Special"), nil)
+	assertInFails(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), TZWithMiutesOffsetFromUTC(5000), nil)
+	assertInFails(t, NewDate(2020, 1, 1), TZAtUTC(), nil)
+	assertInFails(t, ZeroTimestamp(), TZAtUTC(), nil)
+
+	defer SetLatLongResolver(nil)
+	SetLatLongResolver(func(latitudeHundredths, longitudeHundredths int) (*gotime.Location, error) {
+		return gotime.LoadLocation("Asia/Tokyo")
+	})
+	assertIn(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC()), TZAtLatLong(3568, 13976), NewTimestamp(2020, 1, 1, 9, 0, 0, 0, TZAtLatLong(3568, 13976)))
+}
+
+func TestToUTCAndFixedOffset(t *testing.T) {
+	summer := NewTimestamp(2020, 7, 1, 12, 0, 0, 0, TZAtAreaLocation("America/Los_Angeles"))
+	if actual, err := summer.ToUTC(); err != nil || actual != NewTimestamp(2020, 7, 1, 19, 0, 0, 0, TZAtUTC()) {
+		t.Errorf("Expected %v in UTC to be 2020-07-01/19:00:00 but got %v (%v)", summer, actual, err)
+	}
+
+	assertFixedOffset := func(time Time, expected Time) {
+		actual, err := time.ToFixedOffset()
+		if err != nil {
+			t.Errorf("Error getting fixed offset of %v: %v", time, err)
+			return
+		}
+		if actual != expected {
+			t.Errorf("Expected fixed offset of %v to be %v but got %v", time, expected, actual)
+		}
+	}
+	assertFixedOffset(summer, NewTimestamp(2020, 7, 1, 12, 0, 0, 0, TZWithMiutesOffsetFromUTC(-420)))
+	assertFixedOffset(NewTimestamp(2020, 1, 1, 12, 0, 0, 0, TZAtAreaLocation("America/Los_Angeles")), NewTimestamp(2020, 1, 1, 12, 0, 0, 0, TZWithMiutesOffsetFromUTC(-480)))
+	assertFixedOffset(NewTimestamp(2020, 1, 1, 12, 0, 0, 0, TZAtAreaLocation("Europe/London")), NewTimestamp(2020, 1, 1, 12, 0, 0, 0, TZAtUTC()))
+	assertFixedOffset(NewTimestamp(2020, 1, 1, 12, 0, 0, 0, TZWithMiutesOffsetFromUTC(90)), NewTimestamp(2020, 1, 1, 12, 0, 0, 0, TZWithMiutesOffsetFromUTC(90)))
+	assertFixedOffset(NewTimestamp(2016, 12, 31, 23, 59, 60, 0, TZAtAreaLocation("Asia/Tokyo")), NewTimestamp(2016, 12, 31, 23, 59, 60, 0, TZWithMiutesOffsetFromUTC(540)))
+
+	local := NewTimestamp(2020, 1, 1, 12, 0, 0, 0, TZLocal())
+	if _, err := local.ToFixedOffset(); !errors.Is(err, ErrorUnresolvableTimezone) {
+		t.Errorf("Expected the fixed offset of a local timestamp to fail but got %v", err)
+	}
+	lmt := NewTimestamp(1850, 1, 1, 12, 0, 0, 0, TZAtAreaLocation("America/Los_Angeles"))
+	if _, err := lmt.ToFixedOffset(); err == nil {
+		t.Errorf("Expected the fixed offset of %v to fail", lmt)
+	}
+}
+
+func BenchmarkCompareEncodedTimestampsUTC(b *testing.B) {
+	first := encodeAll(b, NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZAtUTC()))
+	second := encodeAll(b, NewTimestamp(2020, 8, 30, 15, 33, 14, 19577324, TZAtUTC()))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := CompareEncodedTimestamps(first, second); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
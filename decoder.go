@@ -41,46 +41,83 @@ var ErrorIncomplete = fmt.Errorf("Compact time value is incomplete")
 
 const RequiredBufferSize = 127
 
+// The part of an encoded value that a DecodeError occurred in.
+type DecodeField int
+
+const (
+	DecodeFieldHeader DecodeField = iota
+	DecodeFieldMagnitude
+	DecodeFieldYear
+	DecodeFieldTimezone
+	DecodeFieldLatLong
+	DecodeFieldUTCOffset
+	DecodeFieldColumnDelta
+	DecodeFieldColumnPrecision
+)
+
+var decodeFieldNames = [...]string{
+	DecodeFieldHeader:          "header",
+	DecodeFieldMagnitude:       "magnitude",
+	DecodeFieldYear:            "year",
+	DecodeFieldTimezone:        "timezone",
+	DecodeFieldLatLong:         "latitude/longitude",
+	DecodeFieldUTCOffset:       "UTC offset",
+	DecodeFieldColumnDelta:     "column delta",
+	DecodeFieldColumnPrecision: "column precision",
+}
+
+func (this DecodeField) String() string {
+	if this >= 0 && int(this) < len(decodeFieldNames) {
+		return decodeFieldNames[this]
+	}
+	return fmt.Sprintf("DecodeField(%d)", int(this))
+}
+
+// DecodeError is returned when an encoded value is invalid or truncated. It
+// records the field being decoded, the byte offset from the start of the
+// value at which the problem was detected, and the underlying cause.
+//
+// Truncated values have ErrorIncomplete as their cause, and also match
+// io.ErrUnexpectedEOF with errors.Is. If the reader has no data at all at the
+// start of a value, io.EOF is returned as-is.
+type DecodeError struct {
+	Field  DecodeField
+	Offset int
+	Err    error
+}
+
+func (this *DecodeError) Error() string {
+	return fmt.Sprintf("Error decoding %v at byte offset %v: %v", this.Field, this.Offset, this.Err)
+}
+
+func (this *DecodeError) Unwrap() error {
+	return this.Err
+}
+
+func (this *DecodeError) Is(target error) bool {
+	return target == io.ErrUnexpectedEOF && this.Err == ErrorIncomplete
+}
+
 // Decode a date.
 func DecodeDate(reader io.Reader) (time Time, bytesDecoded int, err error) {
 	return DecodeDateWithBuffer(reader, makeRequiredBuffer())
 }
 
 func DecodeDateWithBuffer(reader io.Reader, buffer []byte) (time Time, bytesDecoded int, err error) {
-	var year int
-	var month int
-	var day int
-
-	if err = fillSlice(reader, buffer[:2]); err != nil {
-		return
-	}
-	bytesDecoded = 2
-	accumulator := int(decode16LE(buffer))
-	day = int(accumulator & maskDay)
-	accumulator >>= sizeDay
-	month = int(accumulator & maskMonth)
-	accumulator >>= sizeMonth
-	asUint, asBig, byteCount, err := uleb128.DecodeWithByteBuffer(reader, buffer)
-	if err != nil {
+	if err = readFirstField(reader, buffer[:byteCountDate], DecodeFieldHeader); err != nil {
 		return
 	}
+	bytesDecoded = byteCountDate
+	month, day, accumulator := decodeDateBits(uint64(decode16LE(buffer)))
+
+	var encodedYear uint32
+	var byteCount int
+	encodedYear, byteCount, err = decodeYearGroups(reader, buffer, accumulator, yearLowBitCountDate, bytesDecoded)
 	bytesDecoded += byteCount
-	if asBig != nil {
-		err = fmt.Errorf("Year is too big")
-		return
-	}
-	encodedYear := (asUint << 7) | uint64(accumulator)
-	if encodedYear > 0xffffffff || byteCount*7+yearLowBitCountDate > 64 {
-		err = fmt.Errorf("Year is too big")
-		return
-	}
-	year = decodeYear(uint32(encodedYear))
-	if year == 2000 && month == 0 && day == 0 {
-		time = ZeroDate()
+	if err != nil {
 		return
 	}
-
-	time = NewDate(year, month, day)
+	time = newDecodedDate(decodeYear(encodedYear), month, day)
 	return
 }
 
@@ -90,60 +127,37 @@ func DecodeTime(reader io.Reader) (time Time, bytesDecoded int, err error) {
 }
 
 func DecodeTimeWithBuffer(reader io.Reader, buffer []byte) (time Time, bytesDecoded int, err error) {
-	var hour int
-	var minute int
-	var second int
-	var nanosecond int
-	var tz Timezone
-
-	if _, err = reader.Read(buffer[:1]); err != nil {
+	if err = readFirstField(reader, buffer[:1], DecodeFieldHeader); err != nil {
 		return
 	}
-	header := buffer[0]
-
-	magnitude := int((header >> 1) & maskMagnitude)
+	magnitude := decodeMagnitude(buffer[0])
 	baseByteCount := baseByteCountsTime[magnitude]
-	if err = fillSlice(reader, buffer[1:baseByteCount]); err != nil {
+	if err = readField(reader, buffer[1:baseByteCount], DecodeFieldMagnitude, 1); err != nil {
+		bytesDecoded = 1
 		return
 	}
 	bytesDecoded = baseByteCount
 
-	subsecondMultiplier := subsecMultipliers[magnitude]
-	sizeSubseconds := uint(sizeSubsecond * magnitude)
-	maskSubsecond := bitMask(int(sizeSubseconds))
-
-	accumulator := decodeLE(buffer[:baseByteCount], baseByteCount)
-	hasTimezone := accumulator&1 == 1
-	accumulator >>= 1
-	accumulator >>= sizeMagnitude
-	nanosecond = int(accumulator&maskSubsecond) * subsecondMultiplier
-	accumulator >>= sizeSubseconds
-	second = int(accumulator & maskSecond)
-	accumulator >>= sizeSecond
-	minute = int(accumulator & maskMinute)
-	accumulator >>= sizeMinute
-	hour = int(accumulator & maskHour)
-	accumulator >>= sizeHour
-
-	expectedReservedBits := reservedBitsTime[magnitude]
-	if accumulator != expectedReservedBits {
-		if accumulator == 0 {
+	hasTimezone, hour, minute, second, nanosecond, accumulator := decodeTimeOfDayBits(decodeLE(buffer, baseByteCount), magnitude)
+	var isZeroValue bool
+	if isZeroValue, err = checkReservedBitsTime(accumulator, magnitude); err != nil || isZeroValue {
+		if isZeroValue {
 			time = ZeroTime()
-		} else {
-			err = fmt.Errorf("Expected reserved bits %b but got %b", expectedReservedBits, accumulator)
 		}
 		return
 	}
 
-	if !hasTimezone {
-		time.InitTime(hour, minute, second, nanosecond, timezoneUTC)
-		return
+	tz := timezoneUTC
+	if hasTimezone {
+		var byteCount int
+		tz, byteCount, err = decodeTimezone(reader, buffer, bytesDecoded)
+		bytesDecoded += byteCount
+		if err != nil {
+			return
+		}
 	}
-
-	var byteCount int
-	tz, byteCount, err = decodeTimezone(reader, buffer)
-	bytesDecoded += byteCount
 	time.InitTime(hour, minute, second, nanosecond, tz)
+	time.Precision = decodePrecision(magnitude)
 	return
 }
 
@@ -153,98 +167,400 @@ func DecodeTimestamp(reader io.Reader) (time Time, bytesDecoded int, err error)
 }
 
 func DecodeTimestampWithBuffer(reader io.Reader, buffer []byte) (time Time, bytesDecoded int, err error) {
-	var year int
-	var month int
-	var day int
-	var hour int
-	var minute int
-	var second int
-	var nanosecond int
-	var tz Timezone
-
-	if _, err = reader.Read(buffer[:1]); err != nil {
+	if err = readFirstField(reader, buffer[:1], DecodeFieldHeader); err != nil {
 		return
 	}
-	header := buffer[0]
-
-	magnitude := int((header >> 1) & maskMagnitude)
-	subsecondMultiplier := subsecMultipliers[magnitude]
-	sizeSubseconds := uint(sizeSubsecond * magnitude)
-	maskSubsecond := bitMask(int(sizeSubseconds))
+	magnitude := decodeMagnitude(buffer[0])
 	baseByteCount := baseByteCountsTimestamp[magnitude]
-	if err = fillSlice(reader, buffer[1:baseByteCount]); err != nil {
+	if err = readField(reader, buffer[1:baseByteCount], DecodeFieldMagnitude, 1); err != nil {
+		bytesDecoded = 1
 		return
 	}
 	bytesDecoded = baseByteCount
 
-	accumulator := decodeLE(buffer[:baseByteCount], baseByteCount)
-	hasTimezone := accumulator&1 == 1
-	accumulator >>= 1
-	accumulator >>= sizeMagnitude
-	nanosecond = int(accumulator&maskSubsecond) * subsecondMultiplier
-	accumulator >>= sizeSubseconds
-	second = int(accumulator & maskSecond)
-	accumulator >>= sizeSecond
-	minute = int(accumulator & maskMinute)
-	accumulator >>= sizeMinute
-	hour = int(accumulator & maskHour)
-	accumulator >>= sizeHour
-	day = int(accumulator & maskDay)
-	accumulator >>= sizeDay
-	month = int(accumulator & maskMonth)
-	accumulator >>= sizeMonth
+	hasTimezone, hour, minute, second, nanosecond, accumulator := decodeTimeOfDayBits(decodeLE(buffer, baseByteCount), magnitude)
+	month, day, accumulator := decodeDateBits(accumulator)
 
-	yearLowBitCount := yearLowBitCountsTimestamp[magnitude]
-	asUint, asBig, byteCount, err := uleb128.DecodeWithByteBuffer(reader, buffer)
+	var encodedYear uint32
+	var byteCount int
+	encodedYear, byteCount, err = decodeYearGroups(reader, buffer, accumulator, yearLowBitCountsTimestamp[magnitude], bytesDecoded)
+	bytesDecoded += byteCount
 	if err != nil {
 		return
 	}
+	year := decodeYear(encodedYear)
+
+	tz := timezoneUTC
+	if hasTimezone {
+		tz, byteCount, err = decodeTimezone(reader, buffer, bytesDecoded)
+		bytesDecoded += byteCount
+		if err != nil {
+			return
+		}
+	} else if year == yearBias && month == 0 && day == 0 {
+		time = ZeroTimestamp()
+		return
+	}
+
+	time.InitTimestamp(year, month, day, hour, minute, second, nanosecond, tz)
+	time.Precision = decodePrecision(magnitude)
+	return
+}
+
+// Decode a date from the start of src, reporting how many bytes were used.
+//
+// The FromBytes decoders work directly on the source slice and don't allocate
+// unless the value has an area/location time zone that isn't one of the UTC
+// or local aliases. An empty src returns io.EOF, and a truncated value returns
+// a DecodeError with ErrorIncomplete as its cause.
+func DecodeDateFromBytes(src []byte) (time Time, bytesDecoded int, err error) {
+	if err = requireFirstBytes(src, byteCountDate, DecodeFieldHeader); err != nil {
+		return
+	}
+	bytesDecoded = byteCountDate
+	month, day, accumulator := decodeDateBits(uint64(decode16LE(src)))
+
+	var encodedYear uint32
+	var byteCount int
+	encodedYear, byteCount, err = decodeYearGroupsFromBytes(src, accumulator, yearLowBitCountDate, bytesDecoded)
 	bytesDecoded += byteCount
-	if asBig != nil {
-		err = fmt.Errorf("Year is too big")
+	if err != nil {
 		return
 	}
-	encodedYear := (asUint << yearLowBitCount) | uint64(accumulator)
-	if encodedYear > 0xffffffff || byteCount*7+yearLowBitCount > 64 {
-		err = fmt.Errorf("Year is too big")
+	time = newDecodedDate(decodeYear(encodedYear), month, day)
+	return
+}
+
+// Decode a time value from the start of src, reporting how many bytes were
+// used. See DecodeDateFromBytes.
+func DecodeTimeFromBytes(src []byte) (time Time, bytesDecoded int, err error) {
+	if err = requireFirstBytes(src, 1, DecodeFieldHeader); err != nil {
 		return
 	}
-	year = decodeYear(uint32(encodedYear))
+	magnitude := decodeMagnitude(src[0])
+	baseByteCount := baseByteCountsTime[magnitude]
+	if err = requireBytes(src, baseByteCount, DecodeFieldMagnitude); err != nil {
+		bytesDecoded = 1
+		return
+	}
+	bytesDecoded = baseByteCount
 
-	if !hasTimezone {
-		if year == 2000 && month == 0 && day == 0 {
-			time = ZeroTimestamp()
+	hasTimezone, hour, minute, second, nanosecond, accumulator := decodeTimeOfDayBits(decodeLE(src, baseByteCount), magnitude)
+	var isZeroValue bool
+	if isZeroValue, err = checkReservedBitsTime(accumulator, magnitude); err != nil || isZeroValue {
+		if isZeroValue {
+			time = ZeroTime()
+		}
+		return
+	}
+
+	tz := timezoneUTC
+	if hasTimezone {
+		var byteCount int
+		tz, byteCount, err = decodeTimezoneFromBytes(src, bytesDecoded)
+		bytesDecoded += byteCount
+		if err != nil {
 			return
 		}
-		tz = timezoneUTC
-		byteCount = 0
-	} else {
-		tz, byteCount, err = decodeTimezone(reader, buffer)
 	}
+	time.InitTime(hour, minute, second, nanosecond, tz)
+	time.Precision = decodePrecision(magnitude)
+	return
+}
+
+// Decode a timestamp from the start of src, reporting how many bytes were
+// used. See DecodeDateFromBytes.
+func DecodeTimestampFromBytes(src []byte) (time Time, bytesDecoded int, err error) {
+	if err = requireFirstBytes(src, 1, DecodeFieldHeader); err != nil {
+		return
+	}
+	magnitude := decodeMagnitude(src[0])
+	baseByteCount := baseByteCountsTimestamp[magnitude]
+	if err = requireBytes(src, baseByteCount, DecodeFieldMagnitude); err != nil {
+		bytesDecoded = 1
+		return
+	}
+	bytesDecoded = baseByteCount
+
+	hasTimezone, hour, minute, second, nanosecond, accumulator := decodeTimeOfDayBits(decodeLE(src, baseByteCount), magnitude)
+	month, day, accumulator := decodeDateBits(accumulator)
 
+	var encodedYear uint32
+	var byteCount int
+	encodedYear, byteCount, err = decodeYearGroupsFromBytes(src, accumulator, yearLowBitCountsTimestamp[magnitude], bytesDecoded)
 	bytesDecoded += byteCount
+	if err != nil {
+		return
+	}
+	year := decodeYear(encodedYear)
+
+	tz := timezoneUTC
+	if hasTimezone {
+		tz, byteCount, err = decodeTimezoneFromBytes(src, bytesDecoded)
+		bytesDecoded += byteCount
+		if err != nil {
+			return
+		}
+	} else if year == yearBias && month == 0 && day == 0 {
+		time = ZeroTimestamp()
+		return
+	}
 
 	time.InitTimestamp(year, month, day, hour, minute, second, nanosecond, tz)
+	time.Precision = decodePrecision(magnitude)
 	return
 }
 
+// Get the total length of an encoded date from as much of its beginning as is
+// available. If the prefix isn't enough to know the full length, complete is
+// false and needed is the length that must be available to learn more.
+//
+// Values that will fail to decode still get a length as long as their
+// structure can be followed, so that they can be skipped.
+func EncodedLengthDate(prefix []byte) (needed int, complete bool) {
+	if len(prefix) < byteCountDate {
+		return byteCountDate, false
+	}
+	return yearGroupsEnd(prefix, byteCountDate)
+}
+
+// Get the total length of an encoded time. See EncodedLengthDate.
+func EncodedLengthTime(prefix []byte) (needed int, complete bool) {
+	if len(prefix) < 1 {
+		return 1, false
+	}
+	magnitude := decodeMagnitude(prefix[0])
+	baseByteCount := baseByteCountsTime[magnitude]
+	if len(prefix) < baseByteCount {
+		return baseByteCount, false
+	}
+	// Zero values and invalid reserved bits end the value early
+	_, _, _, _, _, reservedBits := decodeTimeOfDayBits(decodeLE(prefix, baseByteCount), magnitude)
+	if reservedBits != reservedBitsTime[magnitude] || prefix[0]&1 == 0 {
+		return baseByteCount, true
+	}
+	return timezoneEnd(prefix, baseByteCount)
+}
+
+// Get the total length of an encoded timestamp. See EncodedLengthDate.
+func EncodedLengthTimestamp(prefix []byte) (needed int, complete bool) {
+	if len(prefix) < 1 {
+		return 1, false
+	}
+	baseByteCount := baseByteCountsTimestamp[decodeMagnitude(prefix[0])]
+	if len(prefix) < baseByteCount {
+		return baseByteCount, false
+	}
+	if needed, complete = yearGroupsEnd(prefix, baseByteCount); !complete || prefix[0]&1 == 0 {
+		return
+	}
+	return timezoneEnd(prefix, needed)
+}
+
 // =============================================================================
 
+// Maximum number of year groups before a value is considered invalid. Valid
+// years need at most 5, but a 64-bit value can have up to 10.
+const maxYearGroupCount = 10
+
+func encodedLength(timeType TimeType, prefix []byte) (needed int, complete bool, err error) {
+	switch timeType {
+	case TimeTypeDate:
+		needed, complete = EncodedLengthDate(prefix)
+	case TimeTypeTime:
+		needed, complete = EncodedLengthTime(prefix)
+	case TimeTypeTimestamp:
+		needed, complete = EncodedLengthTimestamp(prefix)
+	default:
+		err = fmt.Errorf("%v: Unknown time type", timeType)
+	}
+	return
+}
+
+// An overlong year is considered to end after maxYearGroupCount groups, at
+// which point decoding it will fail.
+func yearGroupsEnd(prefix []byte, offset int) (needed int, complete bool) {
+	for index := offset; index < len(prefix); index++ {
+		if prefix[index]&0x80 == 0 || index+1-offset >= maxYearGroupCount {
+			return index + 1, true
+		}
+	}
+	return len(prefix) + 1, false
+}
+
+func timezoneEnd(prefix []byte, offset int) (needed int, complete bool) {
+	if len(prefix) <= offset {
+		return offset + 1, false
+	}
+	header := prefix[offset]
+	switch {
+	case header&maskLatLong != 0:
+		needed = offset + byteCountLatLong
+	case header>>shiftLength == 0:
+		needed = offset + byteCountUTCOffset
+	default:
+		needed = offset + 1 + int(header>>shiftLength)
+	}
+	return needed, true
+}
+
 func makeRequiredBuffer() []byte {
 	return make([]byte, RequiredBufferSize)
 }
 
-func fillSlice(reader io.Reader, dst []byte) (err error) {
-	var bytesRead int
-	for len(dst) > 0 {
-		if bytesRead, err = reader.Read(dst); err != nil {
+func newDecodeError(field DecodeField, offset int, cause error) error {
+	if cause == io.EOF || cause == io.ErrUnexpectedEOF {
+		cause = ErrorIncomplete
+	}
+	return &DecodeError{Field: field, Offset: offset, Err: cause}
+}
+
+// Read the first bytes of a value. If there's no data at all, io.EOF is
+// returned as-is so that callers can detect the end of a sequence of values.
+func readFirstField(reader io.Reader, dst []byte, field DecodeField) error {
+	if bytesRead, err := io.ReadFull(reader, dst); err != nil {
+		if err == io.EOF {
+			return err
+		}
+		return newDecodeError(field, bytesRead, err)
+	}
+	return nil
+}
+
+// Require a value's first bytes. An empty src gives io.EOF, mirroring
+// readFirstField.
+func requireFirstBytes(src []byte, byteCount int, field DecodeField) error {
+	if len(src) == 0 {
+		return io.EOF
+	}
+	return requireBytes(src, byteCount, field)
+}
+
+// Require src to hold at least byteCount bytes from the start of the value.
+func requireBytes(src []byte, byteCount int, field DecodeField) error {
+	if len(src) < byteCount {
+		return newDecodeError(field, len(src), ErrorIncomplete)
+	}
+	return nil
+}
+
+func readField(reader io.Reader, dst []byte, field DecodeField, offset int) error {
+	if bytesRead, err := io.ReadFull(reader, dst); err != nil {
+		return newDecodeError(field, offset+bytesRead, err)
+	}
+	return nil
+}
+
+// Decode the ULEB128 year groups that follow the low year bits, returning the
+// full encoded (zigzag) year.
+func decodeYearGroups(reader io.Reader, buffer []byte, lowBits uint64, lowBitCount int, offset int) (encodedYear uint32, bytesDecoded int, err error) {
+	asUint, asBig, byteCount, err := uleb128.DecodeWithByteBuffer(reader, buffer)
+	bytesDecoded = byteCount
+	if err != nil {
+		err = newDecodeError(DecodeFieldYear, offset+byteCount, err)
+		return
+	}
+	if asBig != nil {
+		err = newDecodeError(DecodeFieldYear, offset, fmt.Errorf("Year is too big"))
+		return
+	}
+	encodedYear, err = combineYearBits(asUint, byteCount, lowBits, lowBitCount, offset)
+	return
+}
+
+// Decode the year groups in place, starting at src[offset].
+func decodeYearGroupsFromBytes(src []byte, lowBits uint64, lowBitCount int, offset int) (encodedYear uint32, bytesDecoded int, err error) {
+	var asUint uint64
+	for index := offset; index < len(src); index++ {
+		group := src[index]
+		if shift := uint(index-offset) * bitsPerYearGroup; shift < 64 {
+			asUint |= uint64(group&0x7f) << shift
+		}
+		if group&0x80 == 0 {
+			bytesDecoded = index + 1 - offset
+			encodedYear, err = combineYearBits(asUint, bytesDecoded, lowBits, lowBitCount, offset)
+			return
+		}
+		if index+1-offset >= maxYearGroupCount {
+			bytesDecoded = index + 1 - offset
+			err = newDecodeError(DecodeFieldYear, offset, fmt.Errorf("Year is too big"))
 			return
 		}
-		dst = dst[bytesRead:]
+	}
+	bytesDecoded = len(src) - offset
+	err = newDecodeError(DecodeFieldYear, len(src), ErrorIncomplete)
+	return
+}
+
+func combineYearBits(highBits uint64, groupCount int, lowBits uint64, lowBitCount int, offset int) (encodedYear uint32, err error) {
+	fullYear := (highBits << uint(lowBitCount)) | lowBits
+	if fullYear > 0xffffffff || groupCount*bitsPerYearGroup+lowBitCount > 64 {
+		err = newDecodeError(DecodeFieldYear, offset, fmt.Errorf("Year is too big"))
+		return
+	}
+	encodedYear = uint32(fullYear)
+	return
+}
+
+func decodeMagnitude(header byte) int {
+	return int((header >> 1) & maskMagnitude)
+}
+
+func decodePrecision(magnitude int) Precision {
+	return PrecisionSeconds + Precision(magnitude)
+}
+
+// Unpack the time zone flag, magnitude and time of day fields, returning the
+// remaining (reserved or date) bits.
+func decodeTimeOfDayBits(accumulator uint64, magnitude int) (hasTimezone bool, hour, minute, second, nanosecond int, remaining uint64) {
+	sizeSubseconds := uint(sizeSubsecond * magnitude)
+	hasTimezone = accumulator&1 == 1
+	accumulator >>= sizeUtc + sizeMagnitude
+	nanosecond = int(accumulator&bitMask(int(sizeSubseconds))) * subsecMultipliers[magnitude]
+	accumulator >>= sizeSubseconds
+	second = int(accumulator & maskSecond)
+	accumulator >>= sizeSecond
+	minute = int(accumulator & maskMinute)
+	accumulator >>= sizeMinute
+	hour = int(accumulator & maskHour)
+	remaining = accumulator >> sizeHour
+	return
+}
+
+func decodeDateBits(accumulator uint64) (month, day int, remaining uint64) {
+	day = int(accumulator & maskDay)
+	accumulator >>= sizeDay
+	month = int(accumulator & maskMonth)
+	remaining = accumulator >> sizeMonth
+	return
+}
+
+// Time values pad the unused high bits with a known pattern, which will only
+// be all zeroes in the zero value.
+func checkReservedBitsTime(reservedBits uint64, magnitude int) (isZeroValue bool, err error) {
+	expectedReservedBits := reservedBitsTime[magnitude]
+	if reservedBits == expectedReservedBits {
+		return
+	}
+	if reservedBits == 0 {
+		isZeroValue = true
+		return
+	}
+	err = &DecodeError{
+		Field:  DecodeFieldMagnitude,
+		Offset: 0,
+		Err:    fmt.Errorf("Expected reserved bits %b but got %b", expectedReservedBits, reservedBits),
 	}
 	return
 }
 
+func newDecodedDate(year, month, day int) Time {
+	if year == yearBias && month == 0 && day == 0 {
+		return ZeroDate()
+	}
+	return NewDate(year, month, day)
+}
+
 func decodeLE(src []byte, byteCount int) uint64 {
 	accumulator := uint64(0)
 	for i := 0; i < byteCount; i++ {
@@ -270,60 +586,117 @@ func decodeYear(encodedYear uint32) int {
 	return int(decodeZigzag32(uint32(encodedYear))) + yearBias
 }
 
-func decodeTimezone(reader io.Reader, buffer []byte) (tz Timezone, bytesDecoded int, err error) {
-	if _, err = reader.Read(buffer[:1]); err != nil {
+func decodeTimezone(reader io.Reader, buffer []byte, offset int) (tz Timezone, bytesDecoded int, err error) {
+	if err = readField(reader, buffer[:1], DecodeFieldTimezone, offset); err != nil {
 		return
 	}
 	header := buffer[0]
+	bytesDecoded = 1
 
 	if header&maskLatLong != 0 {
-		if err = fillSlice(reader, buffer[1:4]); err != nil {
+		if err = readField(reader, buffer[1:byteCountLatLong], DecodeFieldLatLong, offset+1); err != nil {
 			return
 		}
-		latLong := decode32LE(buffer)
-		bytesDecoded = 4
-		longitudeHundredths := int(int32(latLong) >> shiftLongitude)
-		latitudeHundredths := int(int32(latLong<<16) >> 17)
-		tz.InitWithLatLong(latitudeHundredths, longitudeHundredths)
+		bytesDecoded = byteCountLatLong
+		tz = decodeLatLong(buffer)
 		return
 	}
 
-	stringLength := int(header >> 1)
+	stringLength := int(header >> shiftLength)
 	if stringLength == 0 {
-		if err = fillSlice(reader, buffer[0:2]); err != nil {
+		if err = readField(reader, buffer[0:2], DecodeFieldUTCOffset, offset+1); err != nil {
 			return
 		}
-		bytesDecoded = 3
-		minutesRaw := decode16LE(buffer)
-		const maskNegative = 0xf000
-		const maskPositive = 0x0fff
-		var minutes int16
-		if minutesRaw&0x800 != 0 {
-			minutes = int16(minutesRaw | maskNegative)
-		} else {
-			minutes = int16(minutesRaw & maskPositive)
-		}
-		tz.InitWithMinutesOffsetFromUTC(int(minutes))
+		bytesDecoded = byteCountUTCOffset
+		tz = decodeUTCOffset(buffer)
 		return
 	}
 
-	if err = fillSlice(reader, buffer[:stringLength]); err != nil {
+	if err = readField(reader, buffer[:stringLength], DecodeFieldTimezone, offset+1); err != nil {
 		return
 	}
 	bytesDecoded = stringLength + 1
-	if stringLength == 1 {
-		// Avoid a string allocation where possible
-		switch buffer[0] {
-		case 'L':
-			tz = TZLocal()
+	tz = decodeAreaLocation(buffer[:stringLength])
+	return
+}
+
+// Decode a time zone in place, starting at src[offset].
+func decodeTimezoneFromBytes(src []byte, offset int) (tz Timezone, bytesDecoded int, err error) {
+	if err = requireBytes(src, offset+1, DecodeFieldTimezone); err != nil {
+		return
+	}
+	header := src[offset]
+	bytesDecoded = 1
+
+	if header&maskLatLong != 0 {
+		if err = requireBytes(src, offset+byteCountLatLong, DecodeFieldLatLong); err != nil {
 			return
-		case 'Z':
-			tz = TZAtUTC()
+		}
+		bytesDecoded = byteCountLatLong
+		tz = decodeLatLong(src[offset:])
+		return
+	}
+
+	stringLength := int(header >> shiftLength)
+	if stringLength == 0 {
+		if err = requireBytes(src, offset+byteCountUTCOffset, DecodeFieldUTCOffset); err != nil {
 			return
 		}
+		bytesDecoded = byteCountUTCOffset
+		tz = decodeUTCOffset(src[offset+1:])
+		return
+	}
+
+	end := offset + 1 + stringLength
+	if err = requireBytes(src, end, DecodeFieldTimezone); err != nil {
+		return
 	}
-	tz.InitWithAreaLocation(string(buffer[:stringLength]))
+	bytesDecoded = stringLength + 1
+	tz = decodeAreaLocation(src[offset+1 : end])
+	return
+}
+
+// src[0] is the time zone header byte.
+func decodeLatLong(src []byte) (tz Timezone) {
+	latLong := decode32LE(src)
+	longitudeHundredths := int(int32(latLong) >> shiftLongitude)
+	latitudeHundredths := int(int32(latLong<<16) >> 17)
+	tz.InitWithLatLong(latitudeHundredths, longitudeHundredths)
 	return
 }
 
+func decodeUTCOffset(src []byte) (tz Timezone) {
+	minutesRaw := decode16LE(src)
+	const maskNegative = 0xf000
+	const maskPositive = 0x0fff
+	var minutes int16
+	if minutesRaw&0x800 != 0 {
+		minutes = int16(minutesRaw | maskNegative)
+	} else {
+		minutes = int16(minutesRaw & maskPositive)
+	}
+	tz.InitWithMinutesOffsetFromUTC(int(minutes))
+	return
+}
+
+func decodeAreaLocation(areaLocation []byte) Timezone {
+	// Avoid a string allocation where possible
+	if tz, isCommon := commonAreaLocationTimezones[string(areaLocation)]; isCommon {
+		return tz
+	}
+	return TZAtAreaLocation(string(areaLocation))
+}
+
+// Pre-built time zones for the UTC and local aliases, keyed by their encoded
+// area/location string.
+var commonAreaLocationTimezones = func() map[string]Timezone {
+	timezones := make(map[string]Timezone, len(areaLocationToTimezoneType))
+	for areaLocation := range areaLocationToTimezoneType {
+		if areaLocation != "" {
+			timezones[areaLocation] = TZAtAreaLocation(areaLocation)
+		}
+	}
+	return timezones
+}()
+
 var reservedBitsTime = [...]uint64{0x0f, 0x03, 0x00, 0x3f}
@@ -47,9 +47,9 @@ func (this *Time) EncodedSize() int {
 	case TimeTypeDate:
 		return encodedSizeDate(this.Year)
 	case TimeTypeTime:
-		return encodedSizeTime(int(this.Nanosecond), this.Timezone.Type, this.Timezone.ShortAreaLocation)
+		return encodedSizeTime(this.subsecondMagnitude(), this.Timezone.Type, this.Timezone.ShortAreaLocation)
 	case TimeTypeTimestamp:
-		return encodedSizeTimestamp(this.Year, int(this.Nanosecond), this.Timezone.Type, this.Timezone.ShortAreaLocation)
+		return encodedSizeTimestamp(this.Year, this.subsecondMagnitude(), this.Timezone.Type, this.Timezone.ShortAreaLocation)
 	default:
 		panic(fmt.Errorf("%v: Unknown time type", this.Type))
 	}
@@ -78,6 +78,45 @@ func (this *Time) EncodeToBytes(buffer []byte) (bytesEncoded int) {
 	}
 }
 
+// Append the encoded time value (date, time, or timestamp) to dst, growing it
+// as needed. Nothing is allocated if dst already has enough spare capacity.
+//
+// Unlike EncodeToBytes, the value is validated first, and nothing is appended
+// if it's invalid.
+func (this *Time) AppendEncoded(dst []byte) (result []byte, err error) {
+	result = dst
+	if err = this.validateForEncoding(); err != nil {
+		return
+	}
+	var buffer []byte
+	result, buffer = growBytes(dst, this.EncodedSize())
+	this.EncodeToBytes(buffer)
+	return
+}
+
+func (this *Time) validateForEncoding() error {
+	switch this.Type {
+	case TimeTypeDate, TimeTypeTime, TimeTypeTimestamp:
+	default:
+		return fmt.Errorf("%v: Unknown time type", this.Type)
+	}
+	if this.IsZeroValue() {
+		return nil
+	}
+	if this.Type != TimeTypeDate {
+		switch this.Timezone.Type {
+		case TimezoneTypeUTC, TimezoneTypeLocal, TimezoneTypeAreaLocation,
+			TimezoneTypeLatitudeLongitude, TimezoneTypeUTCOffset:
+		default:
+			return fmt.Errorf("%v: Unknown timezone type", this.Timezone.Type)
+		}
+		if len(this.Timezone.ShortAreaLocation) > 127 {
+			return fmt.Errorf("Area/location time zones cannot be over 127 bytes long")
+		}
+	}
+	return this.Validate()
+}
+
 func (this *Time) encodeDate(buffer []byte) (bytesEncoded int) {
 	if this.IsZeroValue() {
 		return encodeZeroDate(buffer)
@@ -93,7 +132,7 @@ func (this *Time) encodeTime(buffer []byte) (bytesEncoded int) {
 
 	isZeroTS := this.Timezone.Type == TimezoneTypeUTC
 	bytesEncoded = encodeTime(int(this.Hour), int(this.Minute),
-		int(this.Second), int(this.Nanosecond), isZeroTS, buffer)
+		int(this.Second), int(this.Nanosecond), this.subsecondMagnitude(), isZeroTS, buffer)
 	if !isZeroTS {
 		bytesEncoded += this.encodeTimezone(buffer[bytesEncoded:])
 	}
@@ -108,7 +147,7 @@ func (this *Time) encodeTimestamp(buffer []byte) (bytesEncoded int) {
 	isZeroTS := this.Timezone.Type == TimezoneTypeUTC
 	bytesEncoded = encodeTimestamp(this.Year, int(this.Month),
 		int(this.Day), int(this.Hour), int(this.Minute), int(this.Second),
-		int(this.Nanosecond), isZeroTS, buffer)
+		int(this.Nanosecond), this.subsecondMagnitude(), isZeroTS, buffer)
 	if !isZeroTS {
 		bytesEncoded += this.encodeTimezone(buffer[bytesEncoded:])
 	}
@@ -133,18 +172,23 @@ func (this *Time) encodeTimezone(buffer []byte) (bytesEncoded int) {
 
 // =============================================================================
 
+// The EncodeGo functions convert the time's location in the same way as
+// AsCompactTime. If the location name is invalid, the UTC offset in effect is
+// still encoded, and the returned error wraps ErrorInvalidLocationName. If the
+// UTC offset is out of range, nothing is encoded.
+
 func EncodedSizeGoDate(time gotime.Time) int {
 	return encodedSizeDate(time.Year())
 }
 
 func EncodedSizeGoTime(time gotime.Time) int {
-	tz := TZAtAreaLocation(time.Location().String())
-	return encodedSizeTime(time.Nanosecond(), tz.Type, tz.ShortAreaLocation)
+	tz, _ := timezoneFromGoTime(time)
+	return encodedSizeTime(getSubsecondMagnitude(time.Nanosecond()), tz.Type, tz.ShortAreaLocation)
 }
 
 func EncodedSizeGoTimestamp(time gotime.Time) int {
-	tz := TZAtAreaLocation(time.Location().String())
-	return encodedSizeTimestamp(time.Year(), time.Nanosecond(), tz.Type, tz.ShortAreaLocation)
+	tz, _ := timezoneFromGoTime(time)
+	return encodedSizeTimestamp(time.Year(), getSubsecondMagnitude(time.Nanosecond()), tz.Type, tz.ShortAreaLocation)
 }
 
 func EncodeGoDate(time gotime.Time, writer io.Writer) (bytesEncoded int, err error) {
@@ -160,55 +204,101 @@ func EncodeGoDateToBytes(time gotime.Time, buffer []byte) (bytesEncoded int) {
 
 func EncodeGoTime(time gotime.Time, writer io.Writer) (bytesEncoded int, err error) {
 	buffer := make([]byte, EncodedSizeGoTime(time))
-	bytesEncoded = EncodeGoTimeToBytes(time, buffer)
-	_, err = writer.Write(buffer[:bytesEncoded])
+	bytesEncoded, err = EncodeGoTimeToBytes(time, buffer)
+	if bytesEncoded == 0 {
+		return
+	}
+	if _, writeErr := writer.Write(buffer[:bytesEncoded]); writeErr != nil {
+		err = writeErr
+	}
 	return
 }
 
-func EncodeGoTimeToBytes(time gotime.Time, buffer []byte) (bytesEncoded int) {
-	tz := TZAtAreaLocation(time.Location().String())
-	bytesEncoded = encodeTime(time.Hour(), time.Minute(),
-		time.Second(), time.Nanosecond(), tz.Type == TimezoneTypeUTC, buffer)
-	if tz.Type != TimezoneTypeUTC {
-		bytesEncoded += encodeTimezoneAreaLoc(tz.ShortAreaLocation, buffer[bytesEncoded:])
+func EncodeGoTimeToBytes(time gotime.Time, buffer []byte) (bytesEncoded int, err error) {
+	var tz Timezone
+	if tz, err = timezoneFromGoTime(time); tz.Validate() != nil {
+		return
 	}
+	compactTime := NewTime(time.Hour(), time.Minute(), time.Second(), time.Nanosecond(), tz)
+	bytesEncoded = compactTime.encodeTime(buffer)
 	return
 }
 
 func EncodeGoTimestamp(time gotime.Time, writer io.Writer) (bytesEncoded int, err error) {
 	buffer := make([]byte, EncodedSizeGoTimestamp(time))
-	bytesEncoded = EncodeGoTimestampToBytes(time, buffer)
-	_, err = writer.Write(buffer[:bytesEncoded])
+	bytesEncoded, err = EncodeGoTimestampToBytes(time, buffer)
+	if bytesEncoded == 0 {
+		return
+	}
+	if _, writeErr := writer.Write(buffer[:bytesEncoded]); writeErr != nil {
+		err = writeErr
+	}
+	return
+}
+
+func EncodeGoTimestampToBytes(time gotime.Time, buffer []byte) (bytesEncoded int, err error) {
+	var compactTime Time
+	if compactTime, err = AsCompactTime(time); compactTime.Timezone.Validate() != nil {
+		return
+	}
+	bytesEncoded = compactTime.encodeTimestamp(buffer)
+	return
+}
+
+func AppendEncodedGoDate(dst []byte, time gotime.Time) []byte {
+	result, buffer := growBytes(dst, EncodedSizeGoDate(time))
+	EncodeGoDateToBytes(time, buffer)
+	return result
+}
+
+func AppendEncodedGoTime(dst []byte, time gotime.Time) (result []byte, err error) {
+	var tz Timezone
+	result = dst
+	if tz, err = timezoneFromGoTime(time); tz.Validate() != nil {
+		return
+	}
+	compactTime := NewTime(time.Hour(), time.Minute(), time.Second(), time.Nanosecond(), tz)
+	var buffer []byte
+	result, buffer = growBytes(dst, compactTime.EncodedSize())
+	compactTime.encodeTime(buffer)
 	return
 }
 
-func EncodeGoTimestampToBytes(time gotime.Time, buffer []byte) (bytesEncoded int) {
-	tz := TZAtAreaLocation(time.Location().String())
-	bytesEncoded = encodeTimestamp(time.Year(), int(time.Month()),
-		time.Day(), time.Hour(), time.Minute(), time.Second(),
-		time.Nanosecond(), tz.Type == TimezoneTypeUTC, buffer)
-	if tz.Type != TimezoneTypeUTC {
-		bytesEncoded += encodeTimezoneAreaLoc(tz.ShortAreaLocation, buffer[bytesEncoded:])
+func AppendEncodedGoTimestamp(dst []byte, time gotime.Time) (result []byte, err error) {
+	var compactTime Time
+	result = dst
+	if compactTime, err = AsCompactTime(time); compactTime.Timezone.Validate() != nil {
+		return
 	}
+	var buffer []byte
+	result, buffer = growBytes(dst, compactTime.EncodedSize())
+	compactTime.encodeTimestamp(buffer)
 	return
 }
 
 // =============================================================================
 
+// Extend dst by byteCount bytes, returning the extended slice and the newly
+// added portion. This only allocates if dst doesn't have enough capacity.
+func growBytes(dst []byte, byteCount int) (result []byte, added []byte) {
+	length := len(dst)
+	result = append(dst, make([]byte, byteCount)...)
+	added = result[length:]
+	return
+}
+
 func encodedSizeDate(year int) int {
 	encodedYear := encodeYear(year)
 	return byteCountDate + getYearGroupCount(encodedYear, yearLowBitCountDate)
 }
 
-func encodedSizeTime(nanosecond int, tzType TimezoneType, shortAreaLocation string) int {
-	magnitude := getSubsecondMagnitude(nanosecond)
+func encodedSizeTime(magnitude int, tzType TimezoneType, shortAreaLocation string) int {
 	baseByteCount := baseByteCountsTime[magnitude]
 
 	return baseByteCount + encodedSizeTimezone(tzType, shortAreaLocation)
 }
 
-func encodedSizeTimestamp(year, nanosecond int, tzType TimezoneType, shortAreaLocation string) int {
-	magnitude := getSubsecondMagnitude(nanosecond)
+func encodedSizeTimestamp(year, magnitude int, tzType TimezoneType, shortAreaLocation string) int {
 	baseByteCount := baseByteCountsTimestamp[magnitude]
 	encodedYear := encodeYear(year)
 	yearGroupCount := getYearGroupCount(encodedYear, yearLowBitCountsTimestamp[magnitude])
@@ -322,8 +412,7 @@ func encodeDate(year, month, day int, buffer []byte) (bytesEncoded int) {
 	return
 }
 
-func encodeTime(hour, minute, second, nanosecond int, isZeroTS bool, buffer []byte) (bytesEncoded int) {
-	magnitude := getSubsecondMagnitude(nanosecond)
+func encodeTime(hour, minute, second, nanosecond, magnitude int, isZeroTS bool, buffer []byte) (bytesEncoded int) {
 	baseByteCount := baseByteCountsTime[magnitude]
 
 	subsecond := nanosecond / subsecMultipliers[magnitude]
@@ -342,9 +431,8 @@ func encodeTime(hour, minute, second, nanosecond int, isZeroTS bool, buffer []by
 	return encodeLE(accumulator, buffer, baseByteCount)
 }
 
-func encodeTimestamp(year, month, day, hour, minute, second, nanosecond int,
+func encodeTimestamp(year, month, day, hour, minute, second, nanosecond, magnitude int,
 	isZeroTS bool, buffer []byte) (bytesEncoded int) {
-	magnitude := getSubsecondMagnitude(nanosecond)
 	baseByteCount := baseByteCountsTimestamp[magnitude]
 
 	subsecond := nanosecond / subsecMultipliers[magnitude]
@@ -0,0 +1,413 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"fmt"
+	"strings"
+	gotime "time"
+)
+
+// Parse an ISO 8601 (or RFC 3339) date, time, or timestamp. The type is
+// inferred from the text.
+//
+// Dates may be calendar (2020-02-15), ordinal (2020-046) or week dates
+// (2020-W07-3), in extended or basic (20200215) format. Years outside of
+// 0000-9999 must carry an explicit sign (+10000, -0500).
+//
+// Note: ISO 8601 years are astronomical: Year 0000 is 1 BC (compact time year
+//       -1), -0001 is 2 BC (year -2), and so on.
+//
+// Times are hh:mm[:ss[.fff]] (or the basic hhmm[ss[.fff]]), optionally
+// followed by Z or a +hh:mm, +hhmm or +hh offset. A time with no offset is
+// local time. A time of day in basic format must be prefixed with 'T'.
+//
+// Timestamps are a date and time separated by 'T'.
+func ParseISO8601(value string) (time Time, err error) {
+	parser := textParser{value: value}
+	if parser.peek() == 'T' || parser.isTimeOfDayNext() {
+		if parser.peek() == 'T' {
+			parser.pos++
+		}
+		var timeOfDay isoTimeOfDay
+		if timeOfDay, err = parser.parseISOTimeOfDay(); err != nil {
+			return
+		}
+		if err = parser.expectEnd(); err != nil {
+			return
+		}
+		time = NewTime(timeOfDay.hour, timeOfDay.minute, timeOfDay.second, timeOfDay.nanosecond, timeOfDay.tz)
+		time.Precision = timeOfDay.precision
+		return
+	}
+
+	var year, month, day int
+	if year, month, day, err = parser.parseISODate(); err != nil {
+		return
+	}
+	if parser.isAtEnd() {
+		time = NewDate(year, month, day)
+		return
+	}
+	if next := parser.peek(); next != 'T' && next != 't' {
+		err = parser.errorAt(parser.pos, "Expected 'T' but got '%c'", next)
+		return
+	}
+	parser.pos++
+	var timeOfDay isoTimeOfDay
+	if timeOfDay, err = parser.parseISOTimeOfDay(); err != nil {
+		return
+	}
+	if err = parser.expectEnd(); err != nil {
+		return
+	}
+	time = NewTimestamp(year, month, day, timeOfDay.hour, timeOfDay.minute,
+		timeOfDay.second, timeOfDay.nanosecond, timeOfDay.tz)
+	time.Precision = timeOfDay.precision
+	return
+}
+
+// Format this time as ISO 8601 extended format (which for timestamps is also
+// valid RFC 3339): 2020-02-15, 13:41:00.5+01:00, 2020-02-15T13:41:00Z.
+// Years before 1 AD are astronomical (see ParseISO8601).
+//
+// Note: ISO 8601 has no representation for area/location or
+//       latitude/longitude time zones. Attempting to format a time with one
+//       of these time zones will result in an error.
+func (this *Time) FormatISO8601() (string, error) {
+	return this.formatISO8601(true)
+}
+
+// Format this time as ISO 8601 basic format: 20200215, 134100.5+0100,
+// 20200215T134100Z.
+//
+// Note: ISO 8601 has no representation for area/location or
+//       latitude/longitude time zones. Attempting to format a time with one
+//       of these time zones will result in an error.
+func (this *Time) FormatISO8601Basic() (string, error) {
+	return this.formatISO8601(false)
+}
+
+// =============================================================================
+
+type isoTimeOfDay struct {
+	hour       int
+	minute     int
+	second     int
+	nanosecond int
+	precision  Precision
+	tz         Timezone
+}
+
+func (this *textParser) countDigits() int {
+	index := this.pos
+	for index < len(this.value) && isDigit(this.value[index]) {
+		index++
+	}
+	return index - this.pos
+}
+
+// Parse an astronomical year (where 0 is 1 BC).
+func (this *textParser) parseISOYear() (year int, err error) {
+	sign := 0
+	switch this.peek() {
+	case '+':
+		sign = 1
+		this.pos++
+	case '-':
+		sign = -1
+		this.pos++
+	}
+	if sign == 0 {
+		year, err = this.parseDigits("year", 4, 4)
+	} else {
+		year, err = this.parseDigits("year", 4, maxYearDigits)
+	}
+	if err != nil {
+		return
+	}
+	if sign < 0 {
+		year = -year
+	}
+	return
+}
+
+func (this *textParser) parseISODate() (year, month, day int, err error) {
+	if year, month, day, err = this.parseISOAstronomicalDate(); err == nil {
+		year = historicalYear(year)
+	}
+	return
+}
+
+func (this *textParser) parseISOAstronomicalDate() (year, month, day int, err error) {
+	start := this.pos
+	if year, err = this.parseISOYear(); err != nil {
+		return
+	}
+
+	isExtended := this.peek() == '-'
+	if isExtended {
+		this.pos++
+	} else if next := this.value[start]; next == '+' || next == '-' {
+		err = this.errorAt(start, "Years with a sign are only supported in extended format")
+		return
+	}
+
+	if this.peek() == 'W' {
+		this.pos++
+		return this.parseISOWeekDate(year, isExtended)
+	}
+
+	start = this.pos
+	if this.countDigits() == 3 {
+		var dayOfYear int
+		if dayOfYear, err = this.parseDigits("day of year", 3, 3); err != nil {
+			return
+		}
+		daysInYear := 365
+		if isLeapYear(year) {
+			daysInYear = 366
+		}
+		if dayOfYear < 1 || dayOfYear > daysInYear {
+			err = this.errorAt(start, "%v: Invalid day of year (must be 1 to %v)", dayOfYear, daysInYear)
+			return
+		}
+		month, day = monthAndDayFromDayOfYear(year, dayOfYear)
+		return
+	}
+
+	if month, err = this.parseRangedField("month", 2, 2, monthMin, monthMax); err != nil {
+		return
+	}
+	if isExtended {
+		if err = this.expectByte('-'); err != nil {
+			return
+		}
+	}
+	start = this.pos
+	if day, err = this.parseRangedField("day", 2, 2, dayMin, int(dayMax[month])); err != nil {
+		return
+	}
+	if month == 2 && day == 29 && !isLeapYear(year) {
+		err = this.errorAt(start, "%v: Invalid day (%v is not a leap year)", day, historicalYear(year))
+	}
+	return
+}
+
+// Parse the week and weekday of a week date. Week dates near the start or end
+// of a year can fall in the adjacent calendar year.
+func (this *textParser) parseISOWeekDate(weekYear int, isExtended bool) (year, month, day int, err error) {
+	start := this.pos
+	var week, weekday int
+	if week, err = this.parseRangedField("week", 2, 2, 1, 53); err != nil {
+		return
+	}
+	if isExtended {
+		if err = this.expectByte('-'); err != nil {
+			return
+		}
+	}
+	if weekday, err = this.parseRangedField("day of week", 1, 1, 1, 7); err != nil {
+		return
+	}
+
+	// January 4th is always in week 1.
+	jan4 := gotime.Date(weekYear, gotime.January, 4, 0, 0, 0, 0, gotime.UTC)
+	week1Monday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+	date := week1Monday.AddDate(0, 0, (week-1)*7+weekday-1)
+	if isoYear, _ := date.ISOWeek(); isoYear != weekYear {
+		err = this.errorAt(start, "%v: Invalid week (year %v doesn't have 53 weeks)", week, weekYear)
+		return
+	}
+	year = date.Year()
+	month = int(date.Month())
+	day = date.Day()
+	return
+}
+
+func (this *textParser) parseISOFraction() (nanosecond int, precision Precision, err error) {
+	if next := this.peek(); next == '.' || next == ',' {
+		this.pos++
+		nanosecond, precision, err = this.parseFraction()
+	}
+	return
+}
+
+func (this *textParser) parseISOTimeOfDay() (result isoTimeOfDay, err error) {
+	if result.hour, err = this.parseRangedField("hour", 2, 2, hourMin, hourMax); err != nil {
+		return
+	}
+	isExtended := this.peek() == ':'
+	if isExtended {
+		this.pos++
+	}
+	if result.minute, err = this.parseRangedField("minute", 2, 2, minuteMin, minuteMax); err != nil {
+		return
+	}
+	hasSeconds := false
+	if isExtended {
+		if this.peek() == ':' {
+			this.pos++
+			hasSeconds = true
+		}
+	} else {
+		hasSeconds = isDigit(this.peek())
+	}
+	if hasSeconds {
+		if result.second, err = this.parseRangedField("second", 2, 2, secondMin, secondMax); err != nil {
+			return
+		}
+		if result.nanosecond, result.precision, err = this.parseISOFraction(); err != nil {
+			return
+		}
+	}
+	result.tz, err = this.parseISOTimezone()
+	return
+}
+
+func (this *textParser) parseISOTimezone() (tz Timezone, err error) {
+	start := this.pos
+	sign := 1
+	switch this.peek() {
+	case 0:
+		tz = TZLocal()
+		return
+	case 'Z', 'z':
+		this.pos++
+		tz = TZAtUTC()
+		return
+	case '+':
+	case '-':
+		sign = -1
+	default:
+		err = this.errorAt(this.pos, "Expected time zone but got '%c'", this.peek())
+		return
+	}
+	this.pos++
+
+	var hour, minute int
+	if hour, err = this.parseDigits("UTC offset hours", 2, 2); err != nil {
+		return
+	}
+	if this.peek() == ':' {
+		this.pos++
+		if minute, err = this.parseRangedField("UTC offset minutes", 2, 2, minuteMin, minuteMax); err != nil {
+			return
+		}
+	} else if isDigit(this.peek()) {
+		if minute, err = this.parseRangedField("UTC offset minutes", 2, 2, minuteMin, minuteMax); err != nil {
+			return
+		}
+	}
+	minutes := sign * (hour*60 + minute)
+	if minutes < minutesFromUTCMin || minutes > minutesFromUTCMax {
+		err = this.errorAt(start, "%v: Invalid UTC offset", minutes)
+		return
+	}
+	tz = TZWithMiutesOffsetFromUTC(minutes)
+	return
+}
+
+func (this *Time) formatISO8601(isExtended bool) (string, error) {
+	if this.IsZeroValue() {
+		return "", fmt.Errorf("A zero time value cannot be represented in ISO 8601")
+	}
+	switch this.Type {
+	case TimeTypeDate:
+		return this.formatISODate(isExtended)
+	case TimeTypeTime:
+		return this.formatISOTime(isExtended)
+	case TimeTypeTimestamp:
+		datePortion, err := this.formatISODate(isExtended)
+		if err != nil {
+			return "", err
+		}
+		timePortion, err := this.formatISOTime(isExtended)
+		if err != nil {
+			return "", err
+		}
+		return datePortion + "T" + timePortion, nil
+	default:
+		return "", fmt.Errorf("%v: Unknown time type", this.Type)
+	}
+}
+
+func (this *Time) formatISODate(isExtended bool) (string, error) {
+	isoYear := astronomicalYear(this.Year)
+	if !isExtended {
+		if isoYear < 0 || isoYear > 9999 {
+			return "", fmt.Errorf("%v: Years outside of 1 BC to 9999 can only be represented in ISO 8601 extended format", this.Year)
+		}
+		return fmt.Sprintf("%04d%02d%02d", isoYear, this.Month, this.Day), nil
+	}
+
+	var year string
+	if isoYear < 0 {
+		year = fmt.Sprintf("-%04d", -isoYear)
+	} else if isoYear > 9999 {
+		year = fmt.Sprintf("+%04d", isoYear)
+	} else {
+		year = fmt.Sprintf("%04d", isoYear)
+	}
+	return fmt.Sprintf("%s-%02d-%02d", year, this.Month, this.Day), nil
+}
+
+func (this *Time) formatISOTime(isExtended bool) (string, error) {
+	var builder strings.Builder
+	if isExtended {
+		builder.WriteString(fmt.Sprintf("%02d:%02d:%02d", this.Hour, this.Minute, this.Second))
+	} else {
+		builder.WriteString(fmt.Sprintf("%02d%02d%02d", this.Hour, this.Minute, this.Second))
+	}
+	builder.WriteString(formatSubseconds(this.Nanosecond, this.Precision))
+
+	switch this.Timezone.Type {
+	case TimezoneTypeUTC:
+		builder.WriteByte('Z')
+	case TimezoneTypeLocal:
+	case TimezoneTypeUTCOffset:
+		sign := '+'
+		minute := int(this.Timezone.MinutesOffsetFromUTC)
+		if minute < 0 {
+			sign = '-'
+			minute = -minute
+		}
+		if isExtended {
+			builder.WriteString(fmt.Sprintf("%c%02d:%02d", sign, minute/60, minute%60))
+		} else {
+			builder.WriteString(fmt.Sprintf("%c%02d%02d", sign, minute/60, minute%60))
+		}
+	case TimezoneTypeAreaLocation, TimezoneTypeLatitudeLongitude:
+		return "", fmt.Errorf("%v: Time zone cannot be represented in ISO 8601", this.Timezone.String())
+	default:
+		return "", fmt.Errorf("%v: Unknown time zone type", this.Timezone.Type)
+	}
+	return builder.String(), nil
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+func monthAndDayFromDayOfYear(year, dayOfYear int) (month, day int) {
+	date := gotime.Date(year, gotime.January, dayOfYear, 0, 0, 0, 0, gotime.UTC)
+	return int(date.Month()), date.Day()
+}
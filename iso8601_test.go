@@ -0,0 +1,129 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"testing"
+)
+
+func assertISO8601(t *testing.T, time Time, expectedExtended, expectedBasic string) {
+	actual, err := time.FormatISO8601()
+	if err != nil {
+		t.Errorf("Error formatting %v as ISO 8601: %v", time, err)
+		return
+	}
+	if actual != expectedExtended {
+		t.Errorf("Expected %v to format as %v but got %v", time, expectedExtended, actual)
+	}
+	actual, err = time.FormatISO8601Basic()
+	if err != nil {
+		t.Errorf("Error formatting %v as ISO 8601 basic: %v", time, err)
+		return
+	}
+	if actual != expectedBasic {
+		t.Errorf("Expected %v to format as %v but got %v", time, expectedBasic, actual)
+	}
+	assertParse(t, ParseISO8601, expectedExtended, time)
+	if time.Type == TimeTypeTime {
+		expectedBasic = "T" + expectedBasic
+	}
+	assertParse(t, ParseISO8601, expectedBasic, time)
+}
+
+func assertISO8601Extended(t *testing.T, time Time, expected string) {
+	actual, err := time.FormatISO8601()
+	if err != nil {
+		t.Errorf("Error formatting %v as ISO 8601: %v", time, err)
+		return
+	}
+	if actual != expected {
+		t.Errorf("Expected %v to format as %v but got %v", time, expected, actual)
+	}
+	assertParse(t, ParseISO8601, expected, time)
+	if actual, err = time.FormatISO8601Basic(); err == nil {
+		t.Errorf("Expected formatting %v as ISO 8601 basic to fail but got %v", time, actual)
+	}
+}
+
+func assertISO8601FormatError(t *testing.T, time Time) {
+	if actual, err := time.FormatISO8601(); err == nil {
+		t.Errorf("Expected formatting %v as ISO 8601 to fail but got %v", time, actual)
+	}
+}
+
+func TestISO8601RoundTrip(t *testing.T) {
+	assertISO8601(t, NewDate(2020, 2, 15), "2020-02-15", "20200215")
+	assertISO8601Extended(t, NewDate(-500, 1, 1), "-0499-01-01")
+	assertISO8601(t, NewDate(-1, 2, 29), "0000-02-29", "00000229")
+	assertISO8601Extended(t, NewDate(12345, 6, 7), "+12345-06-07")
+	assertISO8601(t, NewTimestamp(-1, 12, 31, 23, 0, 0, 0, TZAtUTC()), "0000-12-31T23:00:00Z", "00001231T230000Z")
+	assertISO8601Extended(t, NewTimestamp(-2, 12, 31, 23, 0, 0, 0, TZAtUTC()), "-0001-12-31T23:00:00Z")
+
+	assertISO8601(t, NewTime(13, 41, 0, 0, TZAtUTC()), "13:41:00Z", "134100Z")
+	assertISO8601(t, NewTime(13, 41, 0, 500000000, TZLocal()), "13:41:00.5", "134100.5")
+	assertISO8601(t, NewTime(23, 59, 60, 0, TZWithMiutesOffsetFromUTC(-330)), "23:59:60-05:30", "235960-0530")
+
+	assertISO8601(t, NewTimestamp(2020, 2, 15, 13, 41, 0, 599000, TZAtUTC()), "2020-02-15T13:41:00.000599Z", "20200215T134100.000599Z")
+	assertISO8601(t, NewTimestamp(2020, 2, 15, 13, 41, 0, 0, TZWithMiutesOffsetFromUTC(60)), "2020-02-15T13:41:00+01:00", "20200215T134100+0100")
+	assertISO8601(t, NewTimestamp(2020, 2, 15, 13, 41, 0, 0, TZLocal()), "2020-02-15T13:41:00", "20200215T134100")
+}
+
+func TestISO8601Parse(t *testing.T) {
+	assertParse(t, ParseISO8601, "2020-046", NewDate(2020, 2, 15))
+	assertParse(t, ParseISO8601, "2020046", NewDate(2020, 2, 15))
+	assertParse(t, ParseISO8601, "2020-366", NewDate(2020, 12, 31))
+	assertParse(t, ParseISO8601, "2020-W07-3", NewDate(2020, 2, 12))
+	assertParse(t, ParseISO8601, "2020W073", NewDate(2020, 2, 12))
+	assertParse(t, ParseISO8601, "2020-W01-1", NewDate(2019, 12, 30))
+	assertParse(t, ParseISO8601, "2020-W53-7", NewDate(2021, 1, 3))
+	assertParse(t, ParseISO8601, "0000-060", NewDate(-1, 2, 29))
+	assertParse(t, ParseISO8601, "-0004-02-29", NewDate(-5, 2, 29))
+	assertParse(t, ParseISO8601, "0001-W01-1", NewDate(1, 1, 1))
+	assertParse(t, ParseISO8601, "0000-W52-7", NewDate(-1, 12, 31))
+	assertParse(t, ParseISO8601, "2020-W07-3T10:00Z", NewTimestamp(2020, 2, 12, 10, 0, 0, 0, TZAtUTC()))
+
+	assertParse(t, ParseISO8601, "13:41", NewTime(13, 41, 0, 0, TZLocal()))
+	assertParse(t, ParseISO8601, "T1341", NewTime(13, 41, 0, 0, TZLocal()))
+	assertParse(t, ParseISO8601, "13:41:00,25+01", NewTime(13, 41, 0, 250000000, TZWithMiutesOffsetFromUTC(60)))
+	assertParse(t, ParseISO8601, "13:41:00+00:00", NewTime(13, 41, 0, 0, TZAtUTC()))
+
+	assertParse(t, ParseISO8601, "2020-02-15t13:41:00z", NewTimestamp(2020, 2, 15, 13, 41, 0, 0, TZAtUTC()))
+	assertParse(t, ParseISO8601, "2020-02-15T13:41:00.123456789-08:00", NewTimestamp(2020, 2, 15, 13, 41, 0, 123456789, TZWithMiutesOffsetFromUTC(-480)))
+}
+
+func TestISO8601Errors(t *testing.T) {
+	assertParseError(t, ParseISO8601, "", 0)
+	assertParseError(t, ParseISO8601, "20-02-15", 0)
+	assertParseError(t, ParseISO8601, "-0001-02-29", 9)
+	assertParseError(t, ParseISO8601, "2019-02-29", 8)
+	assertParseError(t, ParseISO8601, "2019-366", 5)
+	assertParseError(t, ParseISO8601, "2019-W53-1", 6)
+	assertParseError(t, ParseISO8601, "2019-W07-8", 9)
+	assertParseError(t, ParseISO8601, "+123450607", 0)
+	assertParseError(t, ParseISO8601, "2020-02-15 13:41:00", 10)
+	assertParseError(t, ParseISO8601, "24:00:00", 0)
+	assertParseError(t, ParseISO8601, "13:41:00/E/Berlin", 8)
+	assertParseError(t, ParseISO8601, "13:41:00+24:00", 8)
+
+	assertISO8601FormatError(t, NewTime(13, 41, 0, 0, TZAtAreaLocation("Europe/Berlin")))
+	assertISO8601FormatError(t, NewTimestamp(2020, 2, 15, 13, 41, 0, 0, TZAtLatLong(100, 100)))
+	assertISO8601FormatError(t, ZeroTimestamp())
+}
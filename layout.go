@@ -0,0 +1,739 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"fmt"
+	"strings"
+	gotime "time"
+)
+
+// Format this time according to a layout. Layouts work the same way as in the
+// go time package: they show how the reference time would be written. The
+// following tokens are recognized:
+//
+//     Year:     2006 06
+//     Month:    January Jan 1 01
+//     Weekday:  Monday Mon
+//     Day:      2 _2 02
+//     Hour:     15 3 03 PM pm
+//     Minute:   4 04
+//     Second:   5 05
+//     Fraction: .000 .999 (any count of 0s or 9s, up to 9)
+//     Offset:   Z07:00 Z0700 Z07 -07:00 -0700 -07
+//
+// As well as the following tokens, which are specific to compact time:
+//
+//     .MAG           Subseconds at the value's magnitude (3, 6 or 9 digits,
+//                    omitted when there are no subseconds)
+//     Area/Location  Long area/location (Europe/Berlin)
+//     A/Location     Short area/location (E/Berlin)
+//     LAT            Latitude in degrees (52.52)
+//     LONG           Longitude in degrees (13.40)
+//
+// Everything else is copied verbatim.
+//
+// Note: An error is returned if the layout contains fields that this time
+//       value doesn't have, such as a year in a time value, or a UTC offset in
+//       a latitude/longitude time value.
+func (this *Time) Format(layout string) (string, error) {
+	if this.IsZeroValue() {
+		return "", fmt.Errorf("Cannot format a zero time value")
+	}
+	var builder strings.Builder
+	for _, chunk := range tokenizeLayout(layout) {
+		if err := this.formatLayoutChunk(&builder, chunk); err != nil {
+			return "", err
+		}
+	}
+	return builder.String(), nil
+}
+
+// Parse a value according to a layout (see Format for the supported tokens).
+// The time type is determined by the layout: date fields only give a date,
+// time fields only give a time, and both give a timestamp.
+//
+// Fields that are missing from the layout default to their lowest value,
+// except for the year, which is required if any date fields are present. If
+// the layout has no time zone fields, the time zone defaults to UTC.
+//
+// Note: Area/location fields consume letters, digits and the characters
+//       "_/+-", so they must be followed by something else in the layout.
+// Note: Like go, the 2006 token accepts 4 digit years. Years with more digits
+//       must have a sign (+12345 or -12345), as written by Format.
+func ParseWithLayout(layout, value string) (time Time, err error) {
+	parser := textParser{value: value}
+	var fields layoutFields
+	for _, chunk := range tokenizeLayout(layout) {
+		if err = parser.parseLayoutChunk(chunk, &fields); err != nil {
+			return
+		}
+	}
+	if err = parser.expectEnd(); err != nil {
+		return
+	}
+	return fields.asTime()
+}
+
+// =============================================================================
+
+type layoutToken int
+
+const (
+	layoutLiteral layoutToken = iota
+	layoutLongYear
+	layoutYear
+	layoutLongMonth
+	layoutMonth
+	layoutNumMonth
+	layoutZeroMonth
+	layoutLongWeekday
+	layoutWeekday
+	layoutDay
+	layoutUnderDay
+	layoutZeroDay
+	layoutHour
+	layoutHour12
+	layoutZeroHour12
+	layoutPM
+	layoutpm
+	layoutMinute
+	layoutZeroMinute
+	layoutSecond
+	layoutZeroSecond
+	layoutFractionFixed
+	layoutFractionTrimmed
+	layoutFractionMagnitude
+	layoutISOColonTZ
+	layoutISOTZ
+	layoutISOShortTZ
+	layoutNumColonTZ
+	layoutNumTZ
+	layoutNumShortTZ
+	layoutLongAreaLocation
+	layoutShortAreaLocation
+	layoutLatitude
+	layoutLongitude
+)
+
+type layoutChunk struct {
+	token layoutToken
+	text  string
+	// Only used by fraction tokens
+	digitCount int
+	separator  byte
+}
+
+// Ordered so that longer tokens are matched before their prefixes.
+var layoutTokens = []struct {
+	text  string
+	token layoutToken
+}{
+	{"January", layoutLongMonth},
+	{"Jan", layoutMonth},
+	{"Monday", layoutLongWeekday},
+	{"Mon", layoutWeekday},
+	{"Area/Location", layoutLongAreaLocation},
+	{"A/Location", layoutShortAreaLocation},
+	{"LAT", layoutLatitude},
+	{"LONG", layoutLongitude},
+	{"2006", layoutLongYear},
+	{"01", layoutZeroMonth},
+	{"02", layoutZeroDay},
+	{"03", layoutZeroHour12},
+	{"04", layoutZeroMinute},
+	{"05", layoutZeroSecond},
+	{"06", layoutYear},
+	{"_2", layoutUnderDay},
+	{"15", layoutHour},
+	{"1", layoutNumMonth},
+	{"2", layoutDay},
+	{"3", layoutHour12},
+	{"4", layoutMinute},
+	{"5", layoutSecond},
+	{"PM", layoutPM},
+	{"pm", layoutpm},
+	{"Z07:00", layoutISOColonTZ},
+	{"Z0700", layoutISOTZ},
+	{"Z07", layoutISOShortTZ},
+	{"-07:00", layoutNumColonTZ},
+	{"-0700", layoutNumTZ},
+	{"-07", layoutNumShortTZ},
+}
+
+func tokenizeLayout(layout string) (chunks []layoutChunk) {
+	literalStart := 0
+	flushLiteral := func(end int) {
+		if end > literalStart {
+			chunks = append(chunks, layoutChunk{token: layoutLiteral, text: layout[literalStart:end]})
+		}
+	}
+
+	for i := 0; i < len(layout); {
+		if chunk, length := matchLayoutToken(layout[i:]); length > 0 {
+			flushLiteral(i)
+			chunks = append(chunks, chunk)
+			i += length
+			literalStart = i
+			continue
+		}
+		i++
+	}
+	flushLiteral(len(layout))
+	return
+}
+
+func matchLayoutToken(layout string) (chunk layoutChunk, length int) {
+	if layout[0] == '.' || layout[0] == ',' {
+		if strings.HasPrefix(layout[1:], "MAG") {
+			return layoutChunk{token: layoutFractionMagnitude, text: layout[:4], separator: layout[0]}, 4
+		}
+		if len(layout) > 1 && (layout[1] == '0' || layout[1] == '9') {
+			digit := layout[1]
+			end := 2
+			for end < len(layout) && layout[end] == digit {
+				end++
+			}
+			// Like go, a fraction can't be followed by more digits.
+			if end-1 <= 9 && (end >= len(layout) || !isDigit(layout[end])) {
+				token := layoutFractionFixed
+				if digit == '9' {
+					token = layoutFractionTrimmed
+				}
+				return layoutChunk{token: token, text: layout[:end], digitCount: end - 1, separator: layout[0]}, end
+			}
+		}
+		return
+	}
+
+	for _, candidate := range layoutTokens {
+		if strings.HasPrefix(layout, candidate.text) {
+			return layoutChunk{token: candidate.token, text: candidate.text}, len(candidate.text)
+		}
+	}
+	return
+}
+
+func (this *Time) hasDateFields() bool {
+	return this.Type == TimeTypeDate || this.Type == TimeTypeTimestamp
+}
+
+func (this *Time) hasTimeFields() bool {
+	return this.Type == TimeTypeTime || this.Type == TimeTypeTimestamp
+}
+
+func (this *Time) formatLayoutChunk(builder *strings.Builder, chunk layoutChunk) error {
+	switch chunk.token {
+	case layoutLiteral:
+		builder.WriteString(chunk.text)
+		return nil
+	case layoutLongYear, layoutYear, layoutLongMonth, layoutMonth, layoutNumMonth,
+		layoutZeroMonth, layoutLongWeekday, layoutWeekday, layoutDay,
+		layoutUnderDay, layoutZeroDay:
+		if !this.hasDateFields() {
+			return fmt.Errorf("%v: Layout field requires a date, but this is a time", chunk.text)
+		}
+	default:
+		if !this.hasTimeFields() {
+			return fmt.Errorf("%v: Layout field requires a time, but this is a date", chunk.text)
+		}
+	}
+
+	switch chunk.token {
+	case layoutLongYear:
+		if this.Year < 0 {
+			builder.WriteString(fmt.Sprintf("-%04d", -this.Year))
+		} else if this.Year > 9999 {
+			builder.WriteString(fmt.Sprintf("+%04d", this.Year))
+		} else {
+			builder.WriteString(fmt.Sprintf("%04d", this.Year))
+		}
+	case layoutYear:
+		year := this.Year % 100
+		if year < 0 {
+			year = -year
+		}
+		builder.WriteString(fmt.Sprintf("%02d", year))
+	case layoutLongMonth:
+		builder.WriteString(gotime.Month(this.Month).String())
+	case layoutMonth:
+		builder.WriteString(gotime.Month(this.Month).String()[:3])
+	case layoutNumMonth:
+		builder.WriteString(fmt.Sprintf("%d", this.Month))
+	case layoutZeroMonth:
+		builder.WriteString(fmt.Sprintf("%02d", this.Month))
+	case layoutLongWeekday:
+		builder.WriteString(this.weekday().String())
+	case layoutWeekday:
+		builder.WriteString(this.weekday().String()[:3])
+	case layoutDay:
+		builder.WriteString(fmt.Sprintf("%d", this.Day))
+	case layoutUnderDay:
+		builder.WriteString(fmt.Sprintf("%2d", this.Day))
+	case layoutZeroDay:
+		builder.WriteString(fmt.Sprintf("%02d", this.Day))
+	case layoutHour:
+		builder.WriteString(fmt.Sprintf("%02d", this.Hour))
+	case layoutHour12:
+		builder.WriteString(fmt.Sprintf("%d", hour12(int(this.Hour))))
+	case layoutZeroHour12:
+		builder.WriteString(fmt.Sprintf("%02d", hour12(int(this.Hour))))
+	case layoutPM:
+		if this.Hour >= 12 {
+			builder.WriteString("PM")
+		} else {
+			builder.WriteString("AM")
+		}
+	case layoutpm:
+		if this.Hour >= 12 {
+			builder.WriteString("pm")
+		} else {
+			builder.WriteString("am")
+		}
+	case layoutMinute:
+		builder.WriteString(fmt.Sprintf("%d", this.Minute))
+	case layoutZeroMinute:
+		builder.WriteString(fmt.Sprintf("%02d", this.Minute))
+	case layoutSecond:
+		builder.WriteString(fmt.Sprintf("%d", this.Second))
+	case layoutZeroSecond:
+		builder.WriteString(fmt.Sprintf("%02d", this.Second))
+	case layoutFractionFixed:
+		builder.WriteByte(chunk.separator)
+		builder.WriteString(fmt.Sprintf("%09d", this.Nanosecond)[:chunk.digitCount])
+	case layoutFractionTrimmed:
+		digits := strings.TrimRight(fmt.Sprintf("%09d", this.Nanosecond)[:chunk.digitCount], "0")
+		if len(digits) > 0 {
+			builder.WriteByte(chunk.separator)
+			builder.WriteString(digits)
+		}
+	case layoutFractionMagnitude:
+		if magnitude := this.subsecondMagnitude(); magnitude > 0 {
+			builder.WriteByte(chunk.separator)
+			builder.WriteString(fmt.Sprintf("%09d", this.Nanosecond)[:magnitude*3])
+		}
+	case layoutISOColonTZ, layoutISOTZ, layoutISOShortTZ, layoutNumColonTZ, layoutNumTZ, layoutNumShortTZ:
+		return this.formatLayoutOffset(builder, chunk)
+	case layoutLongAreaLocation, layoutShortAreaLocation:
+		switch this.Timezone.Type {
+		case TimezoneTypeUTC, TimezoneTypeLocal, TimezoneTypeAreaLocation:
+		default:
+			return fmt.Errorf("%v: Time zone has no area/location", this.Timezone.String())
+		}
+		if chunk.token == layoutLongAreaLocation {
+			builder.WriteString(this.Timezone.LongAreaLocation)
+		} else {
+			builder.WriteString(this.Timezone.ShortAreaLocation)
+		}
+	case layoutLatitude, layoutLongitude:
+		if this.Timezone.Type != TimezoneTypeLatitudeLongitude {
+			return fmt.Errorf("%v: Time zone has no latitude/longitude", this.Timezone.String())
+		}
+		if chunk.token == layoutLatitude {
+			builder.WriteString(fmt.Sprintf("%.2f", float64(this.Timezone.LatitudeHundredths)/100))
+		} else {
+			builder.WriteString(fmt.Sprintf("%.2f", float64(this.Timezone.LongitudeHundredths)/100))
+		}
+	}
+	return nil
+}
+
+func (this *Time) formatLayoutOffset(builder *strings.Builder, chunk layoutChunk) error {
+	minutes := 0
+	switch this.Timezone.Type {
+	case TimezoneTypeUTC:
+		switch chunk.token {
+		case layoutISOColonTZ, layoutISOTZ, layoutISOShortTZ:
+			builder.WriteByte('Z')
+			return nil
+		}
+	case TimezoneTypeUTCOffset:
+		minutes = int(this.Timezone.MinutesOffsetFromUTC)
+	default:
+		return fmt.Errorf("%v: Time zone has no UTC offset", this.Timezone.String())
+	}
+
+	sign := '+'
+	if minutes < 0 {
+		sign = '-'
+		minutes = -minutes
+	}
+	switch chunk.token {
+	case layoutISOColonTZ, layoutNumColonTZ:
+		builder.WriteString(fmt.Sprintf("%c%02d:%02d", sign, minutes/60, minutes%60))
+	case layoutISOTZ, layoutNumTZ:
+		builder.WriteString(fmt.Sprintf("%c%02d%02d", sign, minutes/60, minutes%60))
+	default:
+		builder.WriteString(fmt.Sprintf("%c%02d", sign, minutes/60))
+	}
+	return nil
+}
+
+func (this *Time) weekday() gotime.Weekday {
+	return gotime.Date(astronomicalYear(this.Year), gotime.Month(this.Month), int(this.Day), 0, 0, 0, 0, gotime.UTC).Weekday()
+}
+
+func hour12(hour int) int {
+	hour %= 12
+	if hour == 0 {
+		return 12
+	}
+	return hour
+}
+
+type layoutFields struct {
+	year       int
+	month      int
+	day        int
+	hour       int
+	minute     int
+	second     int
+	nanosecond int
+	precision  Precision
+	latitude   int
+	longitude  int
+	tz         Timezone
+
+	hasDate      bool
+	hasYear      bool
+	hasTime      bool
+	hasHour12    bool
+	hasPM        bool
+	isPM         bool
+	hasTimezone  bool
+	hasLatitude  bool
+	hasLongitude bool
+}
+
+func (this *layoutFields) asTime() (time Time, err error) {
+	if this.hasLatitude != this.hasLongitude {
+		err = fmt.Errorf("Layout must contain both latitude and longitude, or neither")
+		return
+	}
+	if this.hasLatitude {
+		this.tz = TZAtLatLong(this.latitude, this.longitude)
+		this.hasTimezone = true
+	}
+	if !this.hasTimezone {
+		this.tz = TZAtUTC()
+	}
+	if this.hasPM {
+		if !this.hasHour12 {
+			err = fmt.Errorf("Layout contains AM/PM but no 12-hour clock hour")
+			return
+		}
+		this.hour %= 12
+		if this.isPM {
+			this.hour += 12
+		}
+	}
+
+	if this.hasDate {
+		if !this.hasYear {
+			err = fmt.Errorf("Layout contains date fields but no year")
+			return
+		}
+		if this.month == 0 {
+			this.month = 1
+		}
+		if this.day == 0 {
+			this.day = 1
+		}
+		if this.day > int(dayMax[this.month]) {
+			err = fmt.Errorf("%v: Invalid day (must be %v to %v)", this.day, dayMin, dayMax[this.month])
+			return
+		}
+	}
+
+	switch {
+	case this.hasDate && this.hasTime:
+		time = NewTimestamp(this.year, this.month, this.day, this.hour, this.minute, this.second, this.nanosecond, this.tz)
+		time.Precision = this.precision
+	case this.hasDate:
+		time = NewDate(this.year, this.month, this.day)
+	case this.hasTime:
+		time = NewTime(this.hour, this.minute, this.second, this.nanosecond, this.tz)
+		time.Precision = this.precision
+	default:
+		err = fmt.Errorf("Layout contains no date or time fields")
+	}
+	return
+}
+
+func (this *textParser) parseName(names []string) (index int, err error) {
+	start := this.pos
+	for i, name := range names {
+		if len(this.value)-start >= len(name) && strings.EqualFold(this.value[start:start+len(name)], name) {
+			this.pos += len(name)
+			return i, nil
+		}
+	}
+	return 0, this.errorAt(start, "Expected one of %v", names)
+}
+
+func (this *textParser) parseOptionalSpace() {
+	if this.peek() == ' ' {
+		this.pos++
+	}
+}
+
+func (this *textParser) parseLayoutAreaLocation() (tz Timezone, err error) {
+	start := this.pos
+	for !this.isAtEnd() && isAreaLocationChar(this.peek()) {
+		this.pos++
+	}
+	if this.pos == start {
+		err = this.errorAt(start, "Expected area/location")
+		return
+	}
+	tz = TZAtAreaLocation(this.value[start:this.pos])
+	if err = tz.Validate(); err != nil {
+		err = this.errorAt(start, "%v", err)
+	}
+	return
+}
+
+func (this *textParser) parseLayoutOffset(chunk layoutChunk) (tz Timezone, err error) {
+	start := this.pos
+	switch chunk.token {
+	case layoutISOColonTZ, layoutISOTZ, layoutISOShortTZ:
+		if this.peek() == 'Z' {
+			this.pos++
+			tz = TZAtUTC()
+			return
+		}
+	}
+
+	sign := 1
+	switch this.peek() {
+	case '+':
+	case '-':
+		sign = -1
+	default:
+		err = this.errorAt(start, "Expected UTC offset")
+		return
+	}
+	this.pos++
+
+	var hour, minute int
+	if hour, err = this.parseDigits("UTC offset hours", 2, 2); err != nil {
+		return
+	}
+	switch chunk.token {
+	case layoutISOColonTZ, layoutNumColonTZ:
+		if err = this.expectByte(':'); err != nil {
+			return
+		}
+		minute, err = this.parseRangedField("UTC offset minutes", 2, 2, minuteMin, minuteMax)
+	case layoutISOTZ, layoutNumTZ:
+		minute, err = this.parseRangedField("UTC offset minutes", 2, 2, minuteMin, minuteMax)
+	}
+	if err != nil {
+		return
+	}
+	minutes := sign * (hour*60 + minute)
+	if minutes < minutesFromUTCMin || minutes > minutesFromUTCMax {
+		err = this.errorAt(start, "%v: Invalid UTC offset", minutes)
+		return
+	}
+	tz = TZWithMiutesOffsetFromUTC(minutes)
+	return
+}
+
+func (this *textParser) parseLayoutChunk(chunk layoutChunk, fields *layoutFields) (err error) {
+	switch chunk.token {
+	case layoutLiteral:
+		for i := 0; i < len(chunk.text); i++ {
+			if err = this.expectByte(chunk.text[i]); err != nil {
+				return
+			}
+		}
+	case layoutLongYear:
+		start := this.pos
+		isNegative := this.peek() == '-'
+		hasSign := isNegative || this.peek() == '+'
+		if hasSign {
+			this.pos++
+		}
+		maxDigits := 4
+		if hasSign {
+			maxDigits = maxYearDigits
+		}
+		if fields.year, err = this.parseDigits("year", 4, maxDigits); err != nil {
+			return
+		}
+		if fields.year == 0 {
+			return this.errorAt(start, "Year cannot be 0")
+		}
+		if isNegative {
+			fields.year = -fields.year
+		}
+		fields.hasYear = true
+		fields.hasDate = true
+	case layoutYear:
+		if fields.year, err = this.parseDigits("year", 2, 2); err != nil {
+			return
+		}
+		// Same pivot as the go time package
+		if fields.year >= 69 {
+			fields.year += 1900
+		} else {
+			fields.year += 2000
+		}
+		fields.hasYear = true
+		fields.hasDate = true
+	case layoutLongMonth, layoutMonth:
+		names := longMonthNames
+		if chunk.token == layoutMonth {
+			names = shortMonthNames
+		}
+		if fields.month, err = this.parseName(names); err != nil {
+			return
+		}
+		fields.month++
+		fields.hasDate = true
+	case layoutNumMonth:
+		fields.month, err = this.parseRangedField("month", 1, 2, monthMin, monthMax)
+		fields.hasDate = true
+	case layoutZeroMonth:
+		fields.month, err = this.parseRangedField("month", 2, 2, monthMin, monthMax)
+		fields.hasDate = true
+	case layoutLongWeekday, layoutWeekday:
+		// The weekday is implied by the date, and so is only skipped over.
+		names := longWeekdayNames
+		if chunk.token == layoutWeekday {
+			names = shortWeekdayNames
+		}
+		_, err = this.parseName(names)
+		fields.hasDate = true
+	case layoutDay:
+		fields.day, err = this.parseRangedField("day", 1, 2, dayMin, 31)
+		fields.hasDate = true
+	case layoutUnderDay:
+		this.parseOptionalSpace()
+		fields.day, err = this.parseRangedField("day", 1, 2, dayMin, 31)
+		fields.hasDate = true
+	case layoutZeroDay:
+		fields.day, err = this.parseRangedField("day", 2, 2, dayMin, 31)
+		fields.hasDate = true
+	case layoutHour:
+		fields.hour, err = this.parseRangedField("hour", 2, 2, hourMin, hourMax)
+		fields.hasTime = true
+	case layoutHour12:
+		fields.hour, err = this.parseRangedField("hour", 1, 2, 1, 12)
+		fields.hasHour12 = true
+		fields.hasTime = true
+	case layoutZeroHour12:
+		fields.hour, err = this.parseRangedField("hour", 2, 2, 1, 12)
+		fields.hasHour12 = true
+		fields.hasTime = true
+	case layoutPM, layoutpm:
+		var index int
+		if index, err = this.parseName([]string{"AM", "PM"}); err != nil {
+			return
+		}
+		fields.isPM = index == 1
+		fields.hasPM = true
+		fields.hasTime = true
+	case layoutMinute:
+		fields.minute, err = this.parseRangedField("minute", 1, 2, minuteMin, minuteMax)
+		fields.hasTime = true
+	case layoutZeroMinute:
+		fields.minute, err = this.parseRangedField("minute", 2, 2, minuteMin, minuteMax)
+		fields.hasTime = true
+	case layoutSecond:
+		fields.second, err = this.parseRangedField("second", 1, 2, secondMin, secondMax)
+		fields.hasTime = true
+	case layoutZeroSecond:
+		fields.second, err = this.parseRangedField("second", 2, 2, secondMin, secondMax)
+		fields.hasTime = true
+	case layoutFractionFixed:
+		if err = this.expectByte(chunk.separator); err != nil {
+			return
+		}
+		start := this.pos
+		if fields.nanosecond, fields.precision, err = this.parseFraction(); err != nil {
+			return
+		}
+		if this.pos-start != chunk.digitCount {
+			return this.errorAt(start, "Expected %v digits for subseconds", chunk.digitCount)
+		}
+		fields.hasTime = true
+	case layoutFractionTrimmed:
+		if this.peek() == chunk.separator {
+			this.pos++
+			fields.nanosecond, fields.precision, err = this.parseFraction()
+		}
+		fields.hasTime = true
+	case layoutFractionMagnitude:
+		if this.peek() == chunk.separator {
+			this.pos++
+			start := this.pos
+			if fields.nanosecond, fields.precision, err = this.parseFraction(); err != nil {
+				return
+			}
+			if digitCount := this.pos - start; digitCount%3 != 0 {
+				return this.errorAt(start, "Expected 3, 6, or 9 digits for subseconds")
+			}
+		}
+		fields.hasTime = true
+	case layoutISOColonTZ, layoutISOTZ, layoutISOShortTZ, layoutNumColonTZ, layoutNumTZ, layoutNumShortTZ:
+		fields.tz, err = this.parseLayoutOffset(chunk)
+		fields.hasTimezone = true
+		fields.hasTime = true
+	case layoutLongAreaLocation, layoutShortAreaLocation:
+		fields.tz, err = this.parseLayoutAreaLocation()
+		fields.hasTimezone = true
+		fields.hasTime = true
+	case layoutLatitude:
+		fields.latitude, err = this.parseCoordinate("latitude", latitudeMin, latitudeMax)
+		fields.hasLatitude = true
+		fields.hasTime = true
+	case layoutLongitude:
+		fields.longitude, err = this.parseCoordinate("longitude", longitudeMin, longitudeMax)
+		fields.hasLongitude = true
+		fields.hasTime = true
+	}
+	return
+}
+
+func isAreaLocationChar(ch byte) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || isDigit(ch) ||
+		ch == '_' || ch == '/' || ch == '+' || ch == '-'
+}
+
+var longMonthNames = []string{
+	"January", "February", "March", "April", "May", "June",
+	"July", "August", "September", "October", "November", "December",
+}
+
+var shortMonthNames = []string{
+	"Jan", "Feb", "Mar", "Apr", "May", "Jun",
+	"Jul", "Aug", "Sep", "Oct", "Nov", "Dec",
+}
+
+var longWeekdayNames = []string{
+	"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday",
+}
+
+var shortWeekdayNames = []string{
+	"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat",
+}
@@ -0,0 +1,97 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"testing"
+)
+
+func assertLayout(t *testing.T, layout string, time Time, expected string) {
+	actual, err := time.Format(layout)
+	if err != nil {
+		t.Errorf("Error formatting %v with layout %v: %v", time, layout, err)
+		return
+	}
+	if actual != expected {
+		t.Errorf("Expected %v with layout %v to format as %v but got %v", time, layout, expected, actual)
+		return
+	}
+	assertParse(t, func(value string) (Time, error) { return ParseWithLayout(layout, value) }, expected, time)
+}
+
+func assertLayoutFormatError(t *testing.T, layout string, time Time) {
+	if actual, err := time.Format(layout); err == nil {
+		t.Errorf("Expected formatting %v with layout %v to fail but got %v", time, layout, actual)
+	}
+}
+
+func assertLayoutParseError(t *testing.T, layout string, value string) {
+	if actual, err := ParseWithLayout(layout, value); err == nil {
+		t.Errorf("Expected parsing %v with layout %v to fail but got %v", value, layout, actual)
+	}
+}
+
+func TestLayoutRoundTrip(t *testing.T) {
+	assertLayout(t, "2006-01-02", NewDate(2020, 2, 15), "2020-02-15")
+	assertLayout(t, "Monday, January 2, 2006", NewDate(2020, 2, 15), "Saturday, February 15, 2020")
+	assertLayout(t, "Mon _2 Jan 06", NewDate(2020, 2, 5), "Wed  5 Feb 20")
+	assertLayout(t, "2006/1/2", NewDate(-500, 12, 31), "-0500/12/31")
+	assertLayout(t, "Monday 2006-01-02", NewDate(-1, 1, 1), "Saturday -0001-01-01")
+	assertLayout(t, "2006/1/2", NewDate(12345, 6, 7), "+12345/6/7")
+	assertLayout(t, "2006/1/2", NewDate(-12345, 6, 7), "-12345/6/7")
+
+	assertLayout(t, "15:04:05.000", NewTime(13, 41, 0, 5000000, TZAtUTC()), "13:41:00.005")
+	assertLayout(t, "3:4:5.999 PM", NewTime(0, 1, 2, 500000000, TZAtUTC()), "12:1:2.5 AM")
+	assertLayout(t, "03:04pm", NewTime(13, 41, 0, 0, TZAtUTC()), "01:41pm")
+	assertLayout(t, "15:04:05.MAG", NewTime(13, 41, 0, 100000000, TZAtUTC()), "13:41:00.100")
+	assertLayout(t, "15:04:05.MAG", NewTime(13, 41, 0, 100100, TZAtUTC()), "13:41:00.000100100")
+	assertLayout(t, "15:04:05.MAG", NewTime(13, 41, 0, 0, TZAtUTC()), "13:41:00")
+	assertLayout(t, "15:04:05Z07:00", NewTime(13, 41, 0, 0, TZAtUTC()), "13:41:00Z")
+	assertLayout(t, "15:04:05-0700", NewTime(13, 41, 0, 0, TZAtUTC()), "13:41:00+0000")
+	assertLayout(t, "15:04:05 -07:00", NewTime(13, 41, 0, 0, TZWithMiutesOffsetFromUTC(-330)), "13:41:00 -05:30")
+	assertLayout(t, "15:04:05 Area/Location", NewTime(13, 41, 0, 0, TZAtAreaLocation("E/Berlin")), "13:41:00 Europe/Berlin")
+	assertLayout(t, "15:04:05 A/Location", NewTime(13, 41, 0, 0, TZAtAreaLocation("Europe/Berlin")), "13:41:00 E/Berlin")
+	assertLayout(t, "15:04:05 Area/Location", NewTime(13, 41, 0, 0, TZLocal()), "13:41:00 Local")
+	assertLayout(t, "15:04:05 (LAT, LONG)", NewTime(13, 41, 0, 0, TZAtLatLong(5252, -1340)), "13:41:00 (52.52, -13.40)")
+
+	assertLayout(t, "2006-01-02T15:04:05.999999999Z07:00", NewTimestamp(2020, 2, 15, 13, 41, 0, 599000, TZWithMiutesOffsetFromUTC(60)), "2020-02-15T13:41:00.000599+01:00")
+	assertLayout(t, "20060102 150405,000 LAT LONG", NewTimestamp(3190, 8, 31, 0, 54, 47, 394000000, TZAtLatLong(5994, 1071)), "31900831 005447,394 59.94 10.71")
+}
+
+func TestLayoutErrors(t *testing.T) {
+	assertLayoutFormatError(t, "2006", NewTime(13, 41, 0, 0, TZAtUTC()))
+	assertLayoutFormatError(t, "15", NewDate(2020, 2, 15))
+	assertLayoutFormatError(t, "-0700", NewTime(13, 41, 0, 0, TZAtAreaLocation("Europe/Berlin")))
+	assertLayoutFormatError(t, "Area/Location", NewTime(13, 41, 0, 0, TZWithMiutesOffsetFromUTC(60)))
+	assertLayoutFormatError(t, "LAT", NewTime(13, 41, 0, 0, TZAtUTC()))
+	assertLayoutFormatError(t, "2006", ZeroDate())
+
+	assertLayoutParseError(t, "01-02", "02-15")
+	assertLayoutParseError(t, "2006-01-02", "2020-02-30")
+	assertLayoutParseError(t, "2006-01-02", "2020-02-15x")
+	assertLayoutParseError(t, "20060102", "123450607")
+	assertLayoutParseError(t, "15:04 LAT", "13:41 52.52")
+	assertLayoutParseError(t, "15:04 PM", "13:41 PM")
+	assertLayoutParseError(t, "15:04:05.000", "13:41:00.5")
+	assertLayoutParseError(t, "15:04:05.MAG", "13:41:00.5")
+	assertLayoutParseError(t, "15:04-07:00", "13:41Z")
+	assertLayoutParseError(t, "literal", "literal")
+}
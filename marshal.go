@@ -0,0 +1,381 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Marshal to a byte containing the time type, followed by the compact time
+// encoding of the value. This implements encoding.BinaryMarshaler.
+func (this Time) MarshalBinary() ([]byte, error) {
+	// Value receiver so that encoders see it on non-pointer fields
+	return this.AppendEncoded([]byte{byte(this.Type)})
+}
+
+// Unmarshal data produced by MarshalBinary. This implements
+// encoding.BinaryUnmarshaler.
+func (this *Time) UnmarshalBinary(data []byte) (err error) {
+	if len(data) == 0 {
+		return ErrorIncomplete
+	}
+	timeType := TimeType(data[0])
+	reader := bytes.NewReader(data[1:])
+	var time Time
+	var bytesDecoded int
+	switch timeType {
+	case TimeTypeDate:
+		time, bytesDecoded, err = DecodeDate(reader)
+	case TimeTypeTime:
+		time, bytesDecoded, err = DecodeTime(reader)
+	case TimeTypeTimestamp:
+		time, bytesDecoded, err = DecodeTimestamp(reader)
+	default:
+		return fmt.Errorf("%v: Unknown time type", timeType)
+	}
+	if err != nil {
+		if err == io.EOF {
+			err = newDecodeError(DecodeFieldHeader, 0, err)
+		}
+		return
+	}
+	if remaining := len(data) - 1 - bytesDecoded; remaining != 0 {
+		return fmt.Errorf("%v unexpected trailing bytes after %v", remaining, time)
+	}
+	*this = time
+	return
+}
+
+// Marshal to the canonical text form (see Time.String). This implements
+// encoding.TextMarshaler.
+//
+// Zero values have no canonical text form, so they are marshaled in base64
+// binary form instead (see JSONBinaryTime), which preserves their type.
+func (this Time) MarshalText() ([]byte, error) {
+	if this.IsZeroValue() {
+		return this.marshalBase64()
+	}
+	if err := this.Validate(); err != nil {
+		return nil, err
+	}
+	return []byte(this.pString()), nil
+}
+
+// Unmarshal text in canonical or base64 binary form. This implements
+// encoding.TextUnmarshaler.
+func (this *Time) UnmarshalText(data []byte) (err error) {
+	// The first base64 character of a binary time is always 'A' because the
+	// type byte is at most 2, and a canonical text time can't start with 'A'.
+	if len(data) > 0 && data[0] == 'A' {
+		return this.unmarshalBase64(data)
+	}
+	var time Time
+	if time, err = ParseTime(string(data)); err != nil {
+		return
+	}
+	*this = time
+	return
+}
+
+// Marshal to a JSON string containing the canonical text form (see
+// MarshalText). Use JSONObjectTime or JSONBinaryTime for other forms.
+func (this Time) MarshalJSON() ([]byte, error) {
+	text, err := this.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// Unmarshal JSON in any of the canonical text, object (JSONObjectTime), or
+// base64 binary (JSONBinaryTime) forms.
+func (this *Time) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return fmt.Errorf("Cannot unmarshal empty JSON into a time")
+	}
+	switch trimmed[0] {
+	case 'n':
+		// Like go, JSON null is a no-op
+		if string(trimmed) == "null" {
+			return nil
+		}
+	case '"':
+		var text string
+		if err := json.Unmarshal(trimmed, &text); err != nil {
+			return err
+		}
+		return this.UnmarshalText([]byte(text))
+	case '{':
+		var object jsonTimeObject
+		if err := json.Unmarshal(trimmed, &object); err != nil {
+			return err
+		}
+		return object.toTime(this)
+	}
+	return fmt.Errorf("%s: Cannot unmarshal JSON into a time", trimmed)
+}
+
+// A time that marshals to JSON as an object with one member per field, such
+// as {"type":"date","year":2020,"month":1,"day":15}.
+type JSONObjectTime Time
+
+func (this JSONObjectTime) MarshalJSON() ([]byte, error) {
+	var object jsonTimeObject
+	if err := object.fromTime(Time(this)); err != nil {
+		return nil, err
+	}
+	return json.Marshal(object)
+}
+
+func (this *JSONObjectTime) UnmarshalJSON(data []byte) error {
+	return (*Time)(this).UnmarshalJSON(data)
+}
+
+// A time that marshals to JSON as a base64 string of its binary form (see
+// Time.MarshalBinary).
+type JSONBinaryTime Time
+
+func (this JSONBinaryTime) MarshalJSON() ([]byte, error) {
+	time := Time(this)
+	data, err := time.marshalBase64()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(data))
+}
+
+func (this *JSONBinaryTime) UnmarshalJSON(data []byte) error {
+	return (*Time)(this).UnmarshalJSON(data)
+}
+
+// =============================================================================
+
+func (this *Time) marshalBase64() ([]byte, error) {
+	data, err := this.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	encoded := make([]byte, base64.StdEncoding.EncodedLen(len(data)))
+	base64.StdEncoding.Encode(encoded, data)
+	return encoded, nil
+}
+
+func (this *Time) unmarshalBase64(encoded []byte) error {
+	data := make([]byte, base64.StdEncoding.DecodedLen(len(encoded)))
+	byteCount, err := base64.StdEncoding.Decode(data, encoded)
+	if err != nil {
+		return err
+	}
+	return this.UnmarshalBinary(data[:byteCount])
+}
+
+type jsonTimezoneObject struct {
+	Type                string `json:"type"`
+	AreaLocation        string `json:"area_location,omitempty"`
+	LatitudeHundredths  *int16 `json:"latitude_hundredths,omitempty"`
+	LongitudeHundredths *int16 `json:"longitude_hundredths,omitempty"`
+	MinutesOffset       *int16 `json:"minutes_offset_from_utc,omitempty"`
+}
+
+type jsonTimeObject struct {
+	Type       string              `json:"type"`
+	Zero       bool                `json:"zero,omitempty"`
+	Year       *int                `json:"year,omitempty"`
+	Month      *uint8              `json:"month,omitempty"`
+	Day        *uint8              `json:"day,omitempty"`
+	Hour       *uint8              `json:"hour,omitempty"`
+	Minute     *uint8              `json:"minute,omitempty"`
+	Second     *uint8              `json:"second,omitempty"`
+	Nanosecond *uint32             `json:"nanosecond,omitempty"`
+	Precision  string              `json:"precision,omitempty"`
+	Timezone   *jsonTimezoneObject `json:"timezone,omitempty"`
+}
+
+var timeTypeNames = map[TimeType]string{
+	TimeTypeDate:      "date",
+	TimeTypeTime:      "time",
+	TimeTypeTimestamp: "timestamp",
+}
+
+var precisionNames = map[Precision]string{
+	PrecisionSeconds:      "seconds",
+	PrecisionMilliseconds: "milliseconds",
+	PrecisionMicroseconds: "microseconds",
+	PrecisionNanoseconds:  "nanoseconds",
+}
+
+var timezoneTypeNames = map[TimezoneType]string{
+	TimezoneTypeUTC:               "utc",
+	TimezoneTypeLocal:             "local",
+	TimezoneTypeAreaLocation:      "area_location",
+	TimezoneTypeLatitudeLongitude: "latitude_longitude",
+	TimezoneTypeUTCOffset:         "utc_offset",
+}
+
+func (this *jsonTimeObject) fromTime(time Time) error {
+	var ok bool
+	if this.Type, ok = timeTypeNames[time.Type]; !ok {
+		return fmt.Errorf("%v: Unknown time type", time.Type)
+	}
+	if time.IsZeroValue() {
+		this.Zero = true
+		return nil
+	}
+	if err := time.Validate(); err != nil {
+		return err
+	}
+	if time.hasDateFields() {
+		this.Year = &time.Year
+		this.Month = &time.Month
+		this.Day = &time.Day
+	}
+	if time.hasTimeFields() {
+		this.Hour = &time.Hour
+		this.Minute = &time.Minute
+		this.Second = &time.Second
+		this.Nanosecond = &time.Nanosecond
+		this.Precision = precisionNames[time.Precision]
+		this.Timezone = &jsonTimezoneObject{Type: timezoneTypeNames[time.Timezone.Type]}
+		switch time.Timezone.Type {
+		case TimezoneTypeAreaLocation:
+			this.Timezone.AreaLocation = time.Timezone.LongAreaLocation
+		case TimezoneTypeLatitudeLongitude:
+			this.Timezone.LatitudeHundredths = &time.Timezone.LatitudeHundredths
+			this.Timezone.LongitudeHundredths = &time.Timezone.LongitudeHundredths
+		case TimezoneTypeUTCOffset:
+			this.Timezone.MinutesOffset = &time.Timezone.MinutesOffsetFromUTC
+		}
+	}
+	return nil
+}
+
+func (this *jsonTimezoneObject) toTimezone() (tz Timezone, err error) {
+	switch this.Type {
+	case timezoneTypeNames[TimezoneTypeUTC]:
+		tz = TZAtUTC()
+	case timezoneTypeNames[TimezoneTypeLocal]:
+		tz = TZLocal()
+	case timezoneTypeNames[TimezoneTypeAreaLocation]:
+		tz = TZAtAreaLocation(this.AreaLocation)
+	case timezoneTypeNames[TimezoneTypeLatitudeLongitude]:
+		if this.LatitudeHundredths == nil || this.LongitudeHundredths == nil {
+			err = fmt.Errorf("Latitude/longitude time zone requires both latitude_hundredths and longitude_hundredths")
+			return
+		}
+		tz = TZAtLatLong(int(*this.LatitudeHundredths), int(*this.LongitudeHundredths))
+	case timezoneTypeNames[TimezoneTypeUTCOffset]:
+		if this.MinutesOffset == nil {
+			err = fmt.Errorf("UTC offset time zone requires minutes_offset_from_utc")
+			return
+		}
+		tz = TZWithMiutesOffsetFromUTC(int(*this.MinutesOffset))
+	default:
+		err = fmt.Errorf("%v: Unknown time zone type", this.Type)
+	}
+	return
+}
+
+func (this *jsonTimeObject) toTime(dst *Time) (err error) {
+	var time Time
+	found := false
+	for timeType, name := range timeTypeNames {
+		if name == this.Type {
+			time.Type = timeType
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("%v: Unknown time type", this.Type)
+	}
+	if this.Zero {
+		*dst = time
+		return nil
+	}
+
+	fields := []struct {
+		name       string
+		isPresent  bool
+		isForDate  bool
+		isRequired bool
+	}{
+		{"year", this.Year != nil, true, true},
+		{"month", this.Month != nil, true, true},
+		{"day", this.Day != nil, true, true},
+		{"hour", this.Hour != nil, false, true},
+		{"minute", this.Minute != nil, false, true},
+		{"second", this.Second != nil, false, true},
+		{"nanosecond", this.Nanosecond != nil, false, false},
+		{"precision", this.Precision != "", false, false},
+		{"timezone", this.Timezone != nil, false, true},
+	}
+	for _, field := range fields {
+		isExpected := time.hasTimeFields()
+		if field.isForDate {
+			isExpected = time.hasDateFields()
+		}
+		if field.isPresent && !isExpected {
+			return fmt.Errorf("%v: Unexpected field for time type %v", field.name, this.Type)
+		}
+		if !field.isPresent && isExpected && field.isRequired {
+			return fmt.Errorf("%v: Missing field for time type %v", field.name, this.Type)
+		}
+	}
+
+	if time.hasDateFields() {
+		time.Year = *this.Year
+		time.Month = *this.Month
+		time.Day = *this.Day
+		time.Timezone.Type = TimezoneTypeLocal
+	}
+	if time.hasTimeFields() {
+		time.Hour = *this.Hour
+		time.Minute = *this.Minute
+		time.Second = *this.Second
+		if this.Nanosecond != nil {
+			time.Nanosecond = *this.Nanosecond
+		}
+		if this.Precision != "" {
+			found = false
+			for precision, name := range precisionNames {
+				if name == this.Precision {
+					time.Precision = precision
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("%v: Unknown precision", this.Precision)
+			}
+		}
+		if time.Timezone, err = this.Timezone.toTimezone(); err != nil {
+			return
+		}
+	}
+	if err = time.Validate(); err != nil {
+		return
+	}
+	*dst = time
+	return
+}
@@ -0,0 +1,248 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kstenerud/go-describe"
+)
+
+var marshalTestValues = []Time{
+	NewDate(2020, 2, 15),
+	NewDate(-2000, 12, 21),
+	NewTime(8, 41, 5, 999999999, TZAtUTC()),
+	NewTime(10, 10, 10, 0, TZAtAreaLocation("Asia/Tokyo")),
+	NewTime(10, 10, 10, 0, TZAtLatLong(-1354, -17236)),
+	NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZAtAreaLocation("Asia/Singapore")),
+	NewTimestamp(2000, 1, 1, 0, 0, 0, 0, TZWithMiutesOffsetFromUTC(-60)),
+	NewTimestamp(2000, 1, 1, 0, 0, 0, 0, TZLocal()),
+	withPrecision(NewTime(12, 0, 0, 100000000, TZAtUTC()), PrecisionMilliseconds),
+	withPrecision(NewTimestamp(2000, 1, 1, 0, 0, 0, 0, TZAtAreaLocation("Asia/Tokyo")), PrecisionMicroseconds),
+	ZeroDate(),
+	ZeroTime(),
+	ZeroTimestamp(),
+}
+
+func assertMarshalBinary(t *testing.T, expected Time, expectedBytes []byte) {
+	actualBytes, err := expected.MarshalBinary()
+	if err != nil {
+		t.Errorf("Error marshaling %v: %v", expected, err)
+		return
+	}
+	if !bytes.Equal(expectedBytes, actualBytes) {
+		t.Errorf("Expected %v to marshal to %v but got %v", expected, describe.D(expectedBytes), describe.D(actualBytes))
+		return
+	}
+	var actual Time
+	if err = actual.UnmarshalBinary(actualBytes); err != nil {
+		t.Errorf("Error unmarshaling %v: %v", describe.D(actualBytes), err)
+		return
+	}
+	if actual.Type != expected.Type || !expected.IsEquivalentTo(actual) {
+		t.Errorf("Expected %v to unmarshal to %v but got %v", describe.D(actualBytes), expected, actual)
+	}
+}
+
+func assertUnmarshalBinaryError(t *testing.T, data []byte) {
+	var actual Time
+	if err := actual.UnmarshalBinary(data); err == nil {
+		t.Errorf("Expected unmarshaling %v to fail but got %v", describe.D(data), actual)
+	}
+}
+
+func TestMarshalBinary(t *testing.T) {
+	assertMarshalBinary(t, NewDate(2000, 1, 1), []byte{0x00, 0x21, 0x00, 0x00})
+	assertMarshalBinary(t, NewTime(14, 18, 30, 43000000, TZAtUTC()), []byte{0x01, 0x5a, 0xc1, 0x93, 0xdc})
+	assertMarshalBinary(t, NewTimestamp(1966, 12, 1, 5, 13, 5, 0, TZAtUTC()), []byte{0x02, 0x28, 0x9a, 0x12, 0x78, 0x08})
+	assertMarshalBinary(t, ZeroTimestamp(), []byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x00})
+	for _, value := range marshalTestValues {
+		data, err := value.MarshalBinary()
+		if err != nil {
+			t.Errorf("Error marshaling %v: %v", value, err)
+			continue
+		}
+		assertMarshalBinary(t, value, data)
+	}
+
+	invalidTimezone := NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtUTC())
+	invalidTimezone.Timezone.Type = 42
+	invalidPrecision := NewTime(14, 18, 30, 0, TZAtUTC())
+	invalidPrecision.Precision = 7
+	for _, value := range []Time{invalidTimezone, invalidPrecision, NewDate(2020, 13, 1), {Type: 3}} {
+		if data, err := value.MarshalBinary(); err == nil {
+			t.Errorf("Expected marshaling %v to fail but got %v", value, data)
+		}
+	}
+
+	assertUnmarshalBinaryError(t, []byte{})
+	assertUnmarshalBinaryError(t, []byte{0x03, 0x21, 0x00, 0x00})
+	assertUnmarshalBinaryError(t, []byte{0x00, 0x21, 0x00})
+	assertUnmarshalBinaryError(t, []byte{0x00, 0x21, 0x00, 0x00, 0x00})
+}
+
+func TestGob(t *testing.T) {
+	type record struct {
+		Name    string
+		When    Time
+		Pointer *Time
+	}
+	for _, value := range marshalTestValues {
+		valueCopy := value
+		expected := record{Name: "test", When: value, Pointer: &valueCopy}
+		buffer := &bytes.Buffer{}
+		if err := gob.NewEncoder(buffer).Encode(expected); err != nil {
+			t.Errorf("Error gob encoding %v: %v", value, err)
+			continue
+		}
+		var actual record
+		if err := gob.NewDecoder(buffer).Decode(&actual); err != nil {
+			t.Errorf("Error gob decoding %v: %v", value, err)
+			continue
+		}
+		if actual.Name != expected.Name || actual.When.Type != value.Type || !value.IsEquivalentTo(actual.When) ||
+			actual.Pointer == nil || !value.IsEquivalentTo(*actual.Pointer) {
+			t.Errorf("Expected gob to round trip %v but got %v", expected, actual)
+		}
+	}
+}
+
+func assertUnmarshaledJSON(t *testing.T, data []byte, expected Time) {
+	var actual Time
+	if err := json.Unmarshal(data, &actual); err != nil {
+		t.Errorf("Error unmarshaling JSON %s: %v", data, err)
+		return
+	}
+	if actual.Type != expected.Type || actual.Timezone.Type != expected.Timezone.Type || !expected.IsEquivalentTo(actual) {
+		t.Errorf("Expected JSON %s to unmarshal to %v but got %v", data, expected, actual)
+	}
+}
+
+func assertMarshalJSON(t *testing.T, value interface{}, expected Time, expectedJSON string) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Errorf("Error marshaling %v to JSON: %v", expected, err)
+		return
+	}
+	if expectedJSON != "" && string(data) != expectedJSON {
+		t.Errorf("Expected %v to marshal to JSON %v but got %s", expected, expectedJSON, data)
+		return
+	}
+	assertUnmarshaledJSON(t, data, expected)
+}
+
+func assertUnmarshalJSONError(t *testing.T, data string) {
+	var actual Time
+	if err := json.Unmarshal([]byte(data), &actual); err == nil {
+		t.Errorf("Expected unmarshaling JSON %v to fail but got %v", data, actual)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	assertMarshalJSON(t, NewDate(2020, 1, 15), NewDate(2020, 1, 15), `"2020-01-15"`)
+	assertMarshalJSON(t, NewTimestamp(2020, 1, 15, 13, 41, 0, 599000, TZAtAreaLocation("America/New_York")),
+		NewTimestamp(2020, 1, 15, 13, 41, 0, 599000, TZAtAreaLocation("America/New_York")),
+		`"2020-01-15/13:41:00.000599/America/New_York"`)
+	assertMarshalJSON(t, JSONObjectTime(NewDate(2020, 1, 15)), NewDate(2020, 1, 15),
+		`{"type":"date","year":2020,"month":1,"day":15}`)
+	assertMarshalJSON(t, JSONObjectTime(NewTime(13, 41, 0, 0, TZWithMiutesOffsetFromUTC(60))),
+		NewTime(13, 41, 0, 0, TZWithMiutesOffsetFromUTC(60)),
+		`{"type":"time","hour":13,"minute":41,"second":0,"nanosecond":0,"timezone":{"type":"utc_offset","minutes_offset_from_utc":60}}`)
+	assertMarshalJSON(t, JSONObjectTime(NewTime(13, 41, 0, 0, TZAtLatLong(0, -50))),
+		NewTime(13, 41, 0, 0, TZAtLatLong(0, -50)),
+		`{"type":"time","hour":13,"minute":41,"second":0,"nanosecond":0,"timezone":{"type":"latitude_longitude","latitude_hundredths":0,"longitude_hundredths":-50}}`)
+	assertMarshalJSON(t, JSONObjectTime(withPrecision(NewTime(13, 41, 0, 0, TZAtUTC()), PrecisionMilliseconds)),
+		withPrecision(NewTime(13, 41, 0, 0, TZAtUTC()), PrecisionMilliseconds),
+		`{"type":"time","hour":13,"minute":41,"second":0,"nanosecond":0,"precision":"milliseconds","timezone":{"type":"utc"}}`)
+	assertMarshalJSON(t, JSONObjectTime(ZeroTime()), ZeroTime(), `{"type":"time","zero":true}`)
+	assertMarshalJSON(t, JSONBinaryTime(NewDate(2000, 1, 1)), NewDate(2000, 1, 1), `"ACEAAA=="`)
+	assertMarshalJSON(t, ZeroTimestamp(), ZeroTimestamp(), `"AgAAAAAA"`)
+
+	for _, value := range marshalTestValues {
+		assertMarshalJSON(t, value, value, "")
+		assertMarshalJSON(t, JSONObjectTime(value), value, "")
+		assertMarshalJSON(t, JSONBinaryTime(value), value, "")
+	}
+
+	type record struct {
+		Text   Time
+		Object JSONObjectTime
+		Binary JSONBinaryTime
+	}
+	expected := record{
+		Text:   NewTime(13, 41, 0, 0, TZLocal()),
+		Object: JSONObjectTime(NewTimestamp(2000, 1, 1, 0, 0, 0, 0, TZAtAreaLocation("E/Berlin"))),
+		Binary: JSONBinaryTime(NewDate(1, 1, 1)),
+	}
+	data, err := json.Marshal(expected)
+	if err != nil {
+		t.Errorf("Error marshaling %v to JSON: %v", expected, err)
+	}
+	var actual record
+	if err = json.Unmarshal(data, &actual); err != nil {
+		t.Errorf("Error unmarshaling JSON %s: %v", data, err)
+	}
+	if actual != expected {
+		t.Errorf("Expected JSON %s to unmarshal to %v but got %v", data, expected, actual)
+	}
+
+	assertUnmarshalJSONError(t, `"2020-13-01"`)
+	assertUnmarshalJSONError(t, `"AAAA"`)
+	assertUnmarshalJSONError(t, `{"type":"time","hour":13,"minute":41,"second":0,"precision":"picoseconds","timezone":{"type":"utc"}}`)
+	assertUnmarshalJSONError(t, `{"type":"date","year":2020,"month":1,"day":15,"precision":"seconds"}`)
+	assertUnmarshalJSONError(t, `12`)
+	assertUnmarshalJSONError(t, `{"type":"week"}`)
+	assertUnmarshalJSONError(t, `{"type":"date","year":2020,"month":1}`)
+	assertUnmarshalJSONError(t, `{"type":"date","year":2020,"month":1,"day":1,"hour":1}`)
+	assertUnmarshalJSONError(t, `{"type":"time","hour":1,"minute":1,"second":1,"timezone":{"type":"utc_offset"}}`)
+	assertUnmarshalJSONError(t, `{"type":"time","hour":25,"minute":1,"second":1,"timezone":{"type":"utc"}}`)
+}
+
+func TestMarshalText(t *testing.T) {
+	for _, value := range marshalTestValues {
+		data, err := value.MarshalText()
+		if err != nil {
+			t.Errorf("Error marshaling %v to text: %v", value, err)
+			continue
+		}
+		var actual Time
+		if err = actual.UnmarshalText(data); err != nil {
+			t.Errorf("Error unmarshaling text %s: %v", data, err)
+			continue
+		}
+		if actual.Type != value.Type || actual.Timezone.Type != value.Timezone.Type || !value.IsEquivalentTo(actual) {
+			t.Errorf("Expected text %s to unmarshal to %v but got %v", data, value, actual)
+		}
+	}
+}
+
+func TestUnmarshalBinaryIncomplete(t *testing.T) {
+	for _, data := range [][]byte{{}, {0x00}, {0x01, 0x5a}, {0x02, 0x28, 0x9a, 0x12, 0x78}} {
+		var actual Time
+		if err := actual.UnmarshalBinary(data); !errors.Is(err, ErrorIncomplete) {
+			t.Errorf("Expected unmarshaling %v to fail with %v but got %v", describe.D(data), ErrorIncomplete, err)
+		}
+	}
+}
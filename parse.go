@@ -0,0 +1,407 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"fmt"
+)
+
+// ParseError reports a problem with a textual time value, and the byte
+// position within the value where the problem was found.
+type ParseError struct {
+	Value    string
+	Position int
+	Message  string
+}
+
+func (this *ParseError) Error() string {
+	return fmt.Sprintf("%v: %v (at position %v)", this.Value, this.Message, this.Position)
+}
+
+// Parse a date, time, or timestamp in the canonical text form produced by
+// Time.String(). The type is inferred from the text.
+//
+// Note: The zero value text form doesn't say which type it belongs to, and so
+//       it is only accepted by ParseDate, ParseTimeOfDay and ParseTimestamp.
+func ParseTime(value string) (time Time, err error) {
+	parser := textParser{value: value}
+	if parser.isTimeOfDayNext() {
+		return parser.parseTimeOfDayValue()
+	}
+
+	var year, month, day int
+	if year, month, day, err = parser.parseDate(); err != nil {
+		return
+	}
+	if parser.isAtEnd() {
+		time = NewDate(year, month, day)
+		return
+	}
+	if err = parser.expectByte('/'); err != nil {
+		return
+	}
+	return parser.parseTimestampRemainder(year, month, day)
+}
+
+// Parse a date in the canonical text form produced by Time.String().
+func ParseDate(value string) (time Time, err error) {
+	if value == zeroValueString {
+		time = ZeroDate()
+		return
+	}
+	parser := textParser{value: value}
+	var year, month, day int
+	if year, month, day, err = parser.parseDate(); err != nil {
+		return
+	}
+	if err = parser.expectEnd(); err != nil {
+		return
+	}
+	time = NewDate(year, month, day)
+	return
+}
+
+// Parse a time of day in the canonical text form produced by Time.String().
+func ParseTimeOfDay(value string) (time Time, err error) {
+	if value == zeroValueString {
+		time = ZeroTime()
+		return
+	}
+	parser := textParser{value: value}
+	return parser.parseTimeOfDayValue()
+}
+
+// Parse a timestamp in the canonical text form produced by Time.String().
+func ParseTimestamp(value string) (time Time, err error) {
+	if value == zeroValueString {
+		time = ZeroTimestamp()
+		return
+	}
+	parser := textParser{value: value}
+	var year, month, day int
+	if year, month, day, err = parser.parseDate(); err != nil {
+		return
+	}
+	if err = parser.expectByte('/'); err != nil {
+		return
+	}
+	return parser.parseTimestampRemainder(year, month, day)
+}
+
+// =============================================================================
+
+const zeroValueString = "<zero time value>"
+
+// Maximum number of digits accepted in a year field. This keeps the year
+// within the range that can be encoded.
+const maxYearDigits = 9
+
+type textParser struct {
+	value string
+	pos   int
+}
+
+func (this *textParser) errorAt(position int, format string, args ...interface{}) error {
+	return &ParseError{
+		Value:    this.value,
+		Position: position,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+func (this *textParser) isAtEnd() bool {
+	return this.pos >= len(this.value)
+}
+
+func (this *textParser) peek() byte {
+	if this.isAtEnd() {
+		return 0
+	}
+	return this.value[this.pos]
+}
+
+func (this *textParser) expectByte(expected byte) error {
+	if this.isAtEnd() {
+		return this.errorAt(this.pos, "Expected '%c' but reached end of value", expected)
+	}
+	if actual := this.value[this.pos]; actual != expected {
+		return this.errorAt(this.pos, "Expected '%c' but got '%c'", expected, actual)
+	}
+	this.pos++
+	return nil
+}
+
+func (this *textParser) expectEnd() error {
+	if !this.isAtEnd() {
+		return this.errorAt(this.pos, "Unexpected trailing characters %q", this.value[this.pos:])
+	}
+	return nil
+}
+
+// Returns true if the value at the current position begins with a time of
+// day (digits followed by a colon) rather than a date.
+func (this *textParser) isTimeOfDayNext() bool {
+	index := this.pos
+	for index < len(this.value) && isDigit(this.value[index]) {
+		index++
+	}
+	return index > this.pos && index < len(this.value) && this.value[index] == ':'
+}
+
+func (this *textParser) parseDigits(name string, minDigits, maxDigits int) (value int, err error) {
+	start := this.pos
+	for !this.isAtEnd() && isDigit(this.peek()) && this.pos-start < maxDigits {
+		value = value*10 + int(this.peek()-'0')
+		this.pos++
+	}
+	if digitCount := this.pos - start; digitCount < minDigits {
+		if minDigits == maxDigits {
+			err = this.errorAt(start, "Expected %v digits for %v", minDigits, name)
+		} else {
+			err = this.errorAt(start, "Expected %v to %v digits for %v", minDigits, maxDigits, name)
+		}
+	}
+	return
+}
+
+func (this *textParser) parseRangedField(name string, minDigits, maxDigits, min, max int) (value int, err error) {
+	start := this.pos
+	if value, err = this.parseDigits(name, minDigits, maxDigits); err != nil {
+		return
+	}
+	if value < min || value > max {
+		err = this.errorAt(start, "%v: Invalid %v (must be %v to %v)", value, name, min, max)
+	}
+	return
+}
+
+func (this *textParser) parseYear() (year int, err error) {
+	start := this.pos
+	isNegative := false
+	if this.peek() == '-' {
+		isNegative = true
+		this.pos++
+	}
+	if year, err = this.parseDigits("year", 1, maxYearDigits); err != nil {
+		return
+	}
+	if year == 0 {
+		err = this.errorAt(start, "Year cannot be 0")
+		return
+	}
+	if isNegative {
+		year = -year
+	}
+	return
+}
+
+func (this *textParser) parseDate() (year, month, day int, err error) {
+	if year, err = this.parseYear(); err != nil {
+		return
+	}
+	if err = this.expectByte('-'); err != nil {
+		return
+	}
+	if month, err = this.parseRangedField("month", 2, 2, monthMin, monthMax); err != nil {
+		return
+	}
+	if err = this.expectByte('-'); err != nil {
+		return
+	}
+	day, err = this.parseRangedField("day", 2, 2, dayMin, int(dayMax[month]))
+	return
+}
+
+// Parse the digits after a decimal point. 3, 6 or 9 digits declare that
+// precision (so that 12:00:00.100 keeps its trailing zeroes). Any other count
+// of digits has had its trailing zeroes trimmed, and so leaves the precision
+// unspecified.
+func (this *textParser) parseFraction() (nanosecond int, precision Precision, err error) {
+	start := this.pos
+	if nanosecond, err = this.parseDigits("subseconds", 1, 9); err != nil {
+		return
+	}
+	digitCount := this.pos - start
+	if digitCount%3 == 0 {
+		precision = PrecisionSeconds + Precision(digitCount/3)
+	}
+	for i := digitCount; i < 9; i++ {
+		nanosecond *= 10
+	}
+	return
+}
+
+func (this *textParser) parseTime() (hour, minute, second, nanosecond int, precision Precision, err error) {
+	if hour, err = this.parseRangedField("hour", 2, 2, hourMin, hourMax); err != nil {
+		return
+	}
+	if err = this.expectByte(':'); err != nil {
+		return
+	}
+	if minute, err = this.parseRangedField("minute", 2, 2, minuteMin, minuteMax); err != nil {
+		return
+	}
+	if err = this.expectByte(':'); err != nil {
+		return
+	}
+	if second, err = this.parseRangedField("second", 2, 2, secondMin, secondMax); err != nil {
+		return
+	}
+	if this.peek() == '.' {
+		this.pos++
+		nanosecond, precision, err = this.parseFraction()
+	}
+	return
+}
+
+// Parse a latitude or longitude in the form [-]d[.d[d]], returning the value
+// in hundredths of a degree.
+func (this *textParser) parseCoordinate(name string, min, max int) (hundredths int, err error) {
+	start := this.pos
+	isNegative := false
+	if this.peek() == '-' {
+		isNegative = true
+		this.pos++
+	}
+	var whole int
+	if whole, err = this.parseDigits(name, 1, 3); err != nil {
+		return
+	}
+	hundredths = whole * 100
+	if this.peek() == '.' {
+		this.pos++
+		fractionStart := this.pos
+		var fraction int
+		if fraction, err = this.parseDigits(name, 1, 2); err != nil {
+			return
+		}
+		if this.pos-fractionStart == 1 {
+			fraction *= 10
+		}
+		hundredths += fraction
+	}
+	if isNegative {
+		hundredths = -hundredths
+	}
+	if hundredths < min || hundredths > max {
+		err = this.errorAt(start, "%v: Invalid %v (must be %v to %v)", hundredths, name, min, max)
+	}
+	return
+}
+
+func (this *textParser) parseUTCOffset() (tz Timezone, err error) {
+	start := this.pos
+	sign := 1
+	if this.peek() == '-' {
+		sign = -1
+	}
+	this.pos++
+	var hour, minute int
+	if hour, err = this.parseDigits("UTC offset hours", 2, 2); err != nil {
+		return
+	}
+	if minute, err = this.parseRangedField("UTC offset minutes", 2, 2, minuteMin, minuteMax); err != nil {
+		return
+	}
+	minutes := sign * (hour*60 + minute)
+	if minutes < minutesFromUTCMin || minutes > minutesFromUTCMax {
+		err = this.errorAt(start, "%v: Invalid UTC offset", minutes)
+		return
+	}
+	tz = TZWithMiutesOffsetFromUTC(minutes)
+	return
+}
+
+func (this *textParser) parseTimezone() (tz Timezone, err error) {
+	switch this.peek() {
+	case 0:
+		tz = TZAtUTC()
+		return
+	case '+', '-':
+		return this.parseUTCOffset()
+	case '/':
+		this.pos++
+	default:
+		err = this.errorAt(this.pos, "Expected time zone but got '%c'", this.peek())
+		return
+	}
+
+	if next := this.peek(); next == '-' || isDigit(next) {
+		var latitude, longitude int
+		if latitude, err = this.parseCoordinate("latitude", latitudeMin, latitudeMax); err != nil {
+			return
+		}
+		if err = this.expectByte('/'); err != nil {
+			return
+		}
+		if longitude, err = this.parseCoordinate("longitude", longitudeMin, longitudeMax); err != nil {
+			return
+		}
+		tz = TZAtLatLong(latitude, longitude)
+		return
+	}
+
+	start := this.pos
+	areaLocation := this.value[start:]
+	if len(areaLocation) == 0 {
+		err = this.errorAt(start, "Expected area/location but reached end of value")
+		return
+	}
+	this.pos = len(this.value)
+	tz = TZAtAreaLocation(areaLocation)
+	if err = tz.Validate(); err != nil {
+		err = this.errorAt(start, "%v", err)
+	}
+	return
+}
+
+func (this *textParser) parseTimeOfDayValue() (time Time, err error) {
+	var hour, minute, second, nanosecond int
+	var precision Precision
+	if hour, minute, second, nanosecond, precision, err = this.parseTime(); err != nil {
+		return
+	}
+	var tz Timezone
+	if tz, err = this.parseTimezone(); err != nil {
+		return
+	}
+	if err = this.expectEnd(); err != nil {
+		return
+	}
+	time = NewTime(hour, minute, second, nanosecond, tz)
+	time.Precision = precision
+	return
+}
+
+func (this *textParser) parseTimestampRemainder(year, month, day int) (time Time, err error) {
+	var timeOfDay Time
+	if timeOfDay, err = this.parseTimeOfDayValue(); err != nil {
+		return
+	}
+	time = NewTimestamp(year, month, day, int(timeOfDay.Hour), int(timeOfDay.Minute),
+		int(timeOfDay.Second), int(timeOfDay.Nanosecond), timeOfDay.Timezone)
+	time.Precision = timeOfDay.Precision
+	return
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
@@ -0,0 +1,128 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"testing"
+)
+
+func assertParseRoundTrip(t *testing.T, expected Time) {
+	str := expected.String()
+	actual, err := ParseTime(str)
+	if err != nil {
+		t.Errorf("Error parsing %v: %v", str, err)
+		return
+	}
+	if !expected.IsEquivalentTo(actual) {
+		t.Errorf("Expected %v to parse to %v but got %v", str, expected, actual)
+	}
+}
+
+func assertParse(t *testing.T, parse func(string) (Time, error), str string, expected Time) {
+	actual, err := parse(str)
+	if err != nil {
+		t.Errorf("Error parsing %v: %v", str, err)
+		return
+	}
+	if !expected.IsEquivalentTo(actual) {
+		t.Errorf("Expected %v to parse to %v but got %v", str, expected, actual)
+	}
+}
+
+func assertParseError(t *testing.T, parse func(string) (Time, error), str string, expectedPosition int) {
+	actual, err := parse(str)
+	if err == nil {
+		t.Errorf("Expected %v to fail parsing but got %v", str, actual)
+		return
+	}
+	parseErr, ok := err.(*ParseError)
+	if !ok {
+		t.Errorf("Expected a *ParseError parsing %v but got %T (%v)", str, err, err)
+		return
+	}
+	if parseErr.Position != expectedPosition {
+		t.Errorf("Expected error parsing %v at position %v but got %v", str, expectedPosition, parseErr)
+	}
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	assertParseRoundTrip(t, NewDate(2020, 1, 15))
+	assertParseRoundTrip(t, NewDate(-2000, 12, 21))
+	assertParseRoundTrip(t, NewDate(1, 1, 1))
+
+	assertParseRoundTrip(t, NewTime(8, 41, 5, 999999999, TZAtUTC()))
+	assertParseRoundTrip(t, NewTime(14, 18, 30, 43000000, TZAtUTC()))
+	assertParseRoundTrip(t, NewTime(10, 10, 10, 0, TZAtAreaLocation("Asia/Tokyo")))
+	assertParseRoundTrip(t, NewTime(10, 10, 10, 0, TZAtAreaLocation("S/Tokyo")))
+	assertParseRoundTrip(t, NewTime(23, 59, 60, 1, TZLocal()))
+	assertParseRoundTrip(t, NewTime(10, 10, 10, 0, TZAtLatLong(-1354, -17236)))
+	assertParseRoundTrip(t, NewTime(8, 41, 5, 0, TZWithMiutesOffsetFromUTC(-500)))
+
+	assertParseRoundTrip(t, NewTimestamp(2020, 1, 15, 13, 41, 0, 599000, TZAtUTC()))
+	assertParseRoundTrip(t, NewTimestamp(2020, 1, 15, 13, 41, 0, 599000, TZLocal()))
+	assertParseRoundTrip(t, NewTimestamp(2020, 1, 15, 13, 41, 0, 599000, TZAtAreaLocation("America/New_York")))
+	assertParseRoundTrip(t, NewTimestamp(2000, 1, 1, 0, 0, 0, 0, TZAtAreaLocation("E/Berlin")))
+	assertParseRoundTrip(t, NewTimestamp(2020, 1, 15, 13, 41, 0, 599000, TZAtLatLong(50, -50)))
+	assertParseRoundTrip(t, NewTimestamp(2020, 1, 15, 13, 41, 0, 599000, TZAtLatLong(9000, 18000)))
+	assertParseRoundTrip(t, NewTimestamp(2020, 1, 15, 13, 41, 0, 599000, TZWithMiutesOffsetFromUTC(60)))
+	assertParseRoundTrip(t, NewTimestamp(2020, 1, 15, 13, 41, 0, 599000, TZWithMiutesOffsetFromUTC(-1)))
+	assertParseRoundTrip(t, NewTimestamp(-50000, 1, 1, 0, 0, 0, 0, TZAtUTC()))
+	assertParseRoundTrip(t, NewTimestamp(3190, 8, 31, 0, 54, 47, 394129000, TZAtLatLong(5994, 1071)))
+}
+
+func TestParseTyped(t *testing.T) {
+	assertParse(t, ParseDate, "2020-01-15", NewDate(2020, 1, 15))
+	assertParse(t, ParseDate, "<zero time value>", ZeroDate())
+	assertParse(t, ParseTimeOfDay, "13:41:00.5", NewTime(13, 41, 0, 500000000, TZAtUTC()))
+	assertParse(t, ParseTimeOfDay, "13:41:00/E/Berlin", NewTime(13, 41, 0, 0, TZAtAreaLocation("Europe/Berlin")))
+	assertParse(t, ParseTimeOfDay, "<zero time value>", ZeroTime())
+	assertParse(t, ParseTimestamp, "2020-01-15/13:41:00/Etc/UTC", NewTimestamp(2020, 1, 15, 13, 41, 0, 0, TZAtUTC()))
+	assertParse(t, ParseTimestamp, "2020-01-15/13:41:00/5.1/-5", NewTimestamp(2020, 1, 15, 13, 41, 0, 0, TZAtLatLong(510, -500)))
+	assertParse(t, ParseTimestamp, "2020-01-15/13:41:00+0000", NewTimestamp(2020, 1, 15, 13, 41, 0, 0, TZAtUTC()))
+	assertParse(t, ParseTimestamp, "<zero time value>", ZeroTimestamp())
+}
+
+func TestParseErrors(t *testing.T) {
+	assertParseError(t, ParseTime, "", 0)
+	assertParseError(t, ParseTime, "<zero time value>", 0)
+	assertParseError(t, ParseTime, "0-01-01", 0)
+	assertParseError(t, ParseTime, "2020-13-01", 5)
+	assertParseError(t, ParseTime, "2020-02-30", 8)
+	assertParseError(t, ParseTime, "2020-01-1", 8)
+	assertParseError(t, ParseTime, "2020-01-15x", 10)
+	assertParseError(t, ParseTime, "24:00:00", 0)
+	assertParseError(t, ParseTime, "12:60:00", 3)
+	assertParseError(t, ParseTime, "12:00:61", 6)
+	assertParseError(t, ParseTime, "12:00:00.", 9)
+	assertParseError(t, ParseTime, "12:00:00.1234567890", 18)
+	assertParseError(t, ParseTime, "12:00:00/", 9)
+	assertParseError(t, ParseTime, "12:00:00/91.00/0", 9)
+	assertParseError(t, ParseTime, "12:00:00/1.00/-180.01", 14)
+	assertParseError(t, ParseTime, "12:00:00/1.00", 13)
+	assertParseError(t, ParseTime, "12:00:00+2400", 8)
+	assertParseError(t, ParseTime, "12:00:00+0060", 11)
+	assertParseError(t, ParseTime, "12:00:00+01", 11)
+	assertParseError(t, ParseTime, "2020-01-15/12:00", 16)
+	assertParseError(t, ParseDate, "2020-01-15/12:00:00", 10)
+	assertParseError(t, ParseTimeOfDay, "2020-01-15", 2)
+	assertParseError(t, ParseTimestamp, "2020-01-15", 10)
+	assertParseError(t, ParseTimestamp, "12:00:00", 2)
+}
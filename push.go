@@ -0,0 +1,103 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+// PushDecoder decodes a single value from data that arrives in arbitrary
+// chunks. Each call to Feed consumes only as many bytes as belong to the value
+// (collecting the header, year groups and time zone as they arrive), so any
+// bytes after the end of the value are left for the caller.
+//
+// Feed returns ErrorIncomplete until the whole value has arrived, at which
+// point done is true and Time() returns the decoded value. The result is the
+// same as that of the reader-based decode functions. Any other error means
+// that the value is invalid, and Feed will keep returning it until Reset.
+type PushDecoder struct {
+	timeType TimeType
+	buffer   [maxPushEncodedLength]byte
+	length   int
+	time     Time
+	done     bool
+	err      error
+}
+
+// Longest possible value: A timestamp with magnitude 3, the maximum number of
+// year groups, and a 127 byte area/location.
+const maxPushEncodedLength = 8 + maxYearGroupCount + 1 + 127
+
+func NewPushDecoder(timeType TimeType) *PushDecoder {
+	this := &PushDecoder{}
+	this.Reset(timeType)
+	return this
+}
+
+// Reset this decoder to decode a new value of the specified type.
+func (this *PushDecoder) Reset(timeType TimeType) {
+	*this = PushDecoder{timeType: timeType}
+}
+
+// Feed the next chunk of data to the decoder.
+func (this *PushDecoder) Feed(src []byte) (consumed int, done bool, err error) {
+	if this.done || this.err != nil {
+		return 0, this.done, this.err
+	}
+
+	for {
+		var needed int
+		var complete bool
+		if needed, complete, err = encodedLength(this.timeType, this.buffer[:this.length]); err != nil {
+			this.err = err
+			return
+		}
+		if this.length < needed {
+			if consumed == len(src) {
+				err = ErrorIncomplete
+				return
+			}
+			byteCount := copy(this.buffer[this.length:needed], src[consumed:])
+			this.length += byteCount
+			consumed += byteCount
+			continue
+		}
+		if complete {
+			break
+		}
+	}
+
+	this.time, _, this.err = decodeAnyTypeFromBytes(this.timeType, this.buffer[:this.length])
+	this.done = this.err == nil
+	return consumed, this.done, this.err
+}
+
+// Get the decoded value. This is only valid once Feed has returned done.
+func (this *PushDecoder) Time() Time {
+	return this.time
+}
+
+func decodeAnyTypeFromBytes(timeType TimeType, src []byte) (time Time, bytesDecoded int, err error) {
+	switch timeType {
+	case TimeTypeDate:
+		return DecodeDateFromBytes(src)
+	case TimeTypeTime:
+		return DecodeTimeFromBytes(src)
+	default:
+		return DecodeTimestampFromBytes(src)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/kstenerud/go-describe"
+)
+
+// Feed the data in chunks of chunkSize, returning the total consumed.
+func feedInChunks(decoder *PushDecoder, data []byte, chunkSize int) (consumed int, done bool, err error) {
+	for consumed < len(data) {
+		end := consumed + chunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		var byteCount int
+		byteCount, done, err = decoder.Feed(data[consumed:end])
+		consumed += byteCount
+		if err != ErrorIncomplete {
+			return
+		}
+	}
+	return
+}
+
+func assertPushDecode(t *testing.T, timeType TimeType, encoded []byte) {
+	var expected Time
+	var expectedErr error
+	switch timeType {
+	case TimeTypeDate:
+		expected, _, expectedErr = DecodeDate(bytes.NewBuffer(encoded))
+	case TimeTypeTime:
+		expected, _, expectedErr = DecodeTime(bytes.NewBuffer(encoded))
+	case TimeTypeTimestamp:
+		expected, _, expectedErr = DecodeTimestamp(bytes.NewBuffer(encoded))
+	}
+
+	data := append(append([]byte{}, encoded...), 0xff, 0xff)
+	for chunkSize := 1; chunkSize <= len(data); chunkSize++ {
+		decoder := NewPushDecoder(timeType)
+		consumed, done, err := feedInChunks(decoder, data, chunkSize)
+		if expectedErr != nil {
+			if err == nil || done {
+				t.Errorf("Expected push decoding %v in chunks of %v to fail like %v but got %v", describe.D(encoded), chunkSize, expectedErr, decoder.Time())
+			}
+			continue
+		}
+		if err != nil || !done {
+			t.Errorf("Error push decoding %v in chunks of %v: %v", describe.D(encoded), chunkSize, err)
+			continue
+		}
+		if consumed != len(encoded) {
+			t.Errorf("Expected push decoding %v to consume %v bytes but got %v", describe.D(encoded), len(encoded), consumed)
+		}
+		if actual := decoder.Time(); actual != expected {
+			t.Errorf("Expected push decoding %v to give %v but got %v", describe.D(encoded), expected, actual)
+		}
+		if consumed, done, err = decoder.Feed(data); consumed != 0 || !done || err != nil {
+			t.Errorf("Expected feeding a finished decoder to do nothing but got %v, %v, %v", consumed, done, err)
+		}
+	}
+}
+
+func TestPushDecoder(t *testing.T) {
+	values := []Time{
+		NewDate(2020, 8, 30),
+		NewDate(-50000, 1, 1),
+		ZeroDate(),
+		NewTime(13, 41, 5, 999999999, TZWithMiutesOffsetFromUTC(-330)),
+		NewTime(13, 41, 5, 0, TZAtUTC()),
+		NewTime(23, 59, 60, 1000, TZAtLatLong(-1354, -17236)),
+		ZeroTime(),
+		NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZAtAreaLocation("Asia/Singapore")),
+		NewTimestamp(1966, 12, 1, 5, 13, 5, 0, TZAtUTC()),
+		NewTimestamp(-50000, 1, 1, 0, 0, 0, 0, TZLocal()),
+		ZeroTimestamp(),
+	}
+	for _, value := range values {
+		assertPushDecode(t, value.Type, encodeAll(t, value))
+	}
+
+	assertPushDecode(t, TimeTypeTime, []byte{0x5b, 0xc1, 0x93, 0x5c, 0x00})
+	assertPushDecode(t, TimeTypeDate, []byte{0x21, 0x00, 0xff, 0xff, 0xff, 0xff, 0x7f})
+	assertPushDecode(t, TimeTypeTimestamp, []byte{0x28, 0x9a, 0x12, 0x78, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01})
+}
+
+func TestPushDecoderIncomplete(t *testing.T) {
+	encoded := encodeAll(t, NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZAtAreaLocation("Asia/Singapore")))
+	decoder := NewPushDecoder(TimeTypeTimestamp)
+	consumed, done, err := decoder.Feed(encoded[:len(encoded)-1])
+	if consumed != len(encoded)-1 || done || !errors.Is(err, ErrorIncomplete) {
+		t.Errorf("Expected a partial value to give ErrorIncomplete but got %v, %v, %v", consumed, done, err)
+	}
+	if consumed, done, err = decoder.Feed(nil); consumed != 0 || done || !errors.Is(err, ErrorIncomplete) {
+		t.Errorf("Expected an empty feed to give ErrorIncomplete but got %v, %v, %v", consumed, done, err)
+	}
+	if consumed, done, err = decoder.Feed(encoded[len(encoded)-1:]); consumed != 1 || !done || err != nil {
+		t.Errorf("Expected the final byte to complete the value but got %v, %v, %v", consumed, done, err)
+	}
+}
+
+func TestPushDecoderUnknownType(t *testing.T) {
+	decoder := NewPushDecoder(TimeType(9))
+	if consumed, done, err := decoder.Feed([]byte{0x21, 0x00, 0x00}); consumed != 0 || done || err == nil {
+		t.Errorf("Expected an unknown time type to fail but got %v, %v, %v", consumed, done, err)
+	}
+	if _, _, err := decoder.Feed([]byte{0x21, 0x00, 0x00}); err == nil {
+		t.Errorf("Expected the error to persist until Reset")
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Length of a sort key without its time zone suffix.
+const SortKeyLength = 12
+
+// Encode a timestamp as a key that sorts (using bytes.Compare) in the same
+// order as CompareEncodedTimestamps would.
+//
+// The key is the UTC instant: the year (offset by 2^31) and the month, day,
+// hour, minute and second as a 64-bit big endian value, followed by the
+// nanoseconds as a 32-bit big endian value. A zero value timestamp encodes
+// to all zeroes.
+//
+// If includeTimezone is true, the original time zone and precision are
+// appended (as in a column's time zone and precision sections) so that
+// DecodeSortKey can restore the timestamp exactly. Keys that only differ by
+// time zone or precision sort by instant first.
+//
+// The instant is found as in Time.Compare, so latitude/longitude time zones
+// need the resolver set by SetLatLongResolver, and area/location timestamps
+// whose local time doesn't exist or is ambiguous can't be encoded. Local
+// timestamps have no known UTC offset, and so return an error wrapping
+// ErrorUnresolvableTimezone.
+func (this *Time) EncodeSortKey(includeTimezone bool) (key []byte, err error) {
+	if this.Type != TimeTypeTimestamp {
+		err = fmt.Errorf("%v: Only timestamps can be encoded as a sort key", this.Type)
+		return
+	}
+	if this.Timezone.Type == TimezoneTypeLocal {
+		err = fmt.Errorf("%v: %w", this, ErrorUnresolvableTimezone)
+		return
+	}
+	var instant instantKey
+	if instant, err = this.orderingKey(); err != nil {
+		return
+	}
+	if int64(int32(instant.year)) != int64(instant.year) {
+		err = fmt.Errorf("%v: Year is too big", instant.year)
+		return
+	}
+
+	key = make([]byte, SortKeyLength)
+	if instant.isNonZero {
+		biasedYear := uint64(int64(instant.year) + sortKeyYearBias)
+		binary.BigEndian.PutUint64(key, biasedYear<<columnFieldsBitCount|uint64(instant.fields))
+		binary.BigEndian.PutUint32(key[8:], instant.nanosecond)
+	}
+	if includeTimezone {
+		key = appendColumnTimezone(key, &this.Timezone)
+		key = append(key, byte(this.Precision))
+	}
+	return
+}
+
+// Decode a timestamp from a key produced by EncodeSortKey. If the key has no
+// time zone suffix, the timestamp will be in UTC with an unspecified
+// precision.
+func DecodeSortKey(key []byte) (time Time, err error) {
+	if len(key) < SortKeyLength {
+		err = newDecodeError(DecodeFieldHeader, len(key), ErrorIncomplete)
+		return
+	}
+	yearAndFields := binary.BigEndian.Uint64(key)
+	nanosecond := binary.BigEndian.Uint32(key[8:])
+	if yearAndFields == 0 && nanosecond == 0 {
+		time = ZeroTimestamp()
+		return
+	}
+	if nanosecond > nanosecondMax {
+		err = newDecodeError(DecodeFieldHeader, 8, fmt.Errorf("%v: Invalid nanosecond", nanosecond))
+		return
+	}
+
+	year := int64(yearAndFields>>columnFieldsBitCount) - sortKeyYearBias
+	tick := columnTick{
+		seconds:    year<<columnFieldsBitCount | int64(yearAndFields&maskColumnFields),
+		subseconds: int64(nanosecond),
+	}
+	tick.apply(&time, 3)
+	time.Timezone = timezoneUTC
+	if err = time.Validate(); err != nil {
+		err = newDecodeError(DecodeFieldHeader, 0, err)
+		return
+	}
+	if len(key) == SortKeyLength {
+		return
+	}
+
+	decoder := columnDecoder{src: key, pos: SortKeyLength}
+	tz := decoder.readColumnTimezone()
+	precision := Precision(decoder.readByte(DecodeFieldColumnPrecision))
+	if decoder.err == nil {
+		if precision > PrecisionNanoseconds {
+			decoder.fail(DecodeFieldColumnPrecision, fmt.Errorf("%v: Invalid precision", precision))
+		} else if decoder.pos != len(key) {
+			decoder.fail(DecodeFieldColumnPrecision, fmt.Errorf("Unexpected data after precision"))
+		}
+	}
+	if decoder.err != nil {
+		err = decoder.err
+		return
+	}
+	time.Precision = precision
+	if err = time.Validate(); err != nil {
+		err = newDecodeError(DecodeFieldColumnPrecision, decoder.pos-1, err)
+		return
+	}
+	return time.In(tz)
+}
+
+// =============================================================================
+
+const sortKeyYearBias = 1 << 31
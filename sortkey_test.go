@@ -0,0 +1,154 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func encodeSortKey(t *testing.T, time Time, includeTimezone bool) []byte {
+	key, err := time.EncodeSortKey(includeTimezone)
+	if err != nil {
+		t.Fatalf("Error encoding sort key for %v: %v", time, err)
+	}
+	return key
+}
+
+func assertSortKeyRoundTrip(t *testing.T, time Time) {
+	key := encodeSortKey(t, time, true)
+	actual, err := DecodeSortKey(key)
+	if err != nil {
+		t.Errorf("Error decoding sort key for %v: %v", time, err)
+		return
+	}
+	if !time.IsEquivalentTo(actual) {
+		t.Errorf("Expected sort key to decode to %v but got %v", time, actual)
+	}
+
+	utcKey := encodeSortKey(t, time, false)
+	if len(utcKey) != SortKeyLength || !bytes.Equal(utcKey, key[:SortKeyLength]) {
+		t.Errorf("Expected sort key of %v without time zone to be a %v byte prefix of %x but got %x", time, SortKeyLength, key, utcKey)
+	}
+	utc, err := DecodeSortKey(utcKey)
+	if err != nil {
+		t.Errorf("Error decoding sort key without time zone for %v: %v", time, err)
+		return
+	}
+	isSame, _ := time.SameInstant(utc)
+	if !time.IsZeroValue() && utc.Timezone.Type != TimezoneTypeUTC || !isSame {
+		t.Errorf("Expected sort key without time zone to decode to %v in UTC but got %v", time, utc)
+	}
+}
+
+func TestSortKeyRoundTrip(t *testing.T) {
+	assertSortKeyRoundTrip(t, ZeroTimestamp())
+	assertSortKeyRoundTrip(t, NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZAtUTC()))
+	assertSortKeyRoundTrip(t, NewTimestamp(-2000000000, 1, 1, 0, 0, 0, 0, TZAtUTC()))
+	assertSortKeyRoundTrip(t, NewTimestamp(-1, 12, 31, 23, 59, 59, 999999999, TZAtUTC()))
+	assertSortKeyRoundTrip(t, NewTimestamp(1, 1, 1, 0, 30, 0, 0, TZWithMiutesOffsetFromUTC(60)))
+	assertSortKeyRoundTrip(t, NewTimestamp(-1, 12, 31, 23, 30, 0, 0, TZWithMiutesOffsetFromUTC(-60)))
+	assertSortKeyRoundTrip(t, NewTimestamp(2016, 12, 31, 23, 59, 60, 0, TZAtUTC()))
+	assertSortKeyRoundTrip(t, NewTimestamp(2016, 12, 31, 20, 59, 60, 0, TZWithMiutesOffsetFromUTC(-180)))
+	assertSortKeyRoundTrip(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, Timezone{Type: TimezoneTypeUTCOffset}))
+	assertSortKeyRoundTrip(t, NewTimestamp(2020, 8, 30, 15, 33, 14, 0, TZAtAreaLocation("America/New_York")))
+	assertSortKeyRoundTrip(t, NewTimestamp(2020, 11, 1, 0, 30, 0, 0, TZAtAreaLocation("America/New_York")))
+	assertSortKeyRoundTrip(t, NewTimestamp(2020, 8, 30, 15, 33, 14, 0, TZAtAreaLocation("Etc/GMT")))
+	assertSortKeyRoundTrip(t, withPrecision(NewTimestamp(2020, 8, 30, 12, 0, 0, 100000000, TZAtUTC()), PrecisionMicroseconds))
+	assertSortKeyRoundTrip(t, withPrecision(NewTimestamp(2020, 8, 30, 12, 0, 0, 0, TZAtAreaLocation("Asia/Tokyo")), PrecisionNanoseconds))
+	assertSortKeyRoundTrip(t, withPrecision(NewTimestamp(-1, 12, 31, 23, 30, 0, 0, TZWithMiutesOffsetFromUTC(-60)), PrecisionSeconds))
+}
+
+func TestSortKeyOrder(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		a := randomTimestamp(random)
+		b := randomTimestamp(random)
+		if random.Intn(10) == 0 {
+			a.Year = -a.Year
+		}
+		expected, err := a.Compare(b)
+		if err != nil {
+			// Local time doesn't exist or is ambiguous
+			continue
+		}
+		if actual := bytes.Compare(encodeSortKey(t, a, false), encodeSortKey(t, b, false)); actual != expected {
+			t.Errorf("Expected sort keys of %v and %v to compare as %v but got %v", a, b, expected, actual)
+		}
+		if expected != 0 {
+			if actual := bytes.Compare(encodeSortKey(t, a, true), encodeSortKey(t, b, true)); actual != expected {
+				t.Errorf("Expected sort keys with time zones of %v and %v to compare as %v but got %v", a, b, expected, actual)
+			}
+		}
+	}
+}
+
+func TestSortKeyErrors(t *testing.T) {
+	invalid := []Time{
+		NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtLatLong(100, 100)),
+		NewTimestamp(2020, 8, 30, 15, 33, 14, 0, TZLocal()),
+		NewTimestamp(2020, 11, 1, 1, 30, 0, 0, TZAtAreaLocation("America/New_York")),
+		NewTimestamp(2020, 13, 1, 0, 0, 0, 0, TZAtUTC()),
+		NewDate(2020, 1, 1),
+		NewTime(10, 0, 0, 0, TZAtUTC()),
+	}
+	for _, time := range invalid {
+		if _, err := time.EncodeSortKey(false); err == nil {
+			t.Errorf("Expected encoding sort key for %v to fail", time)
+		}
+	}
+	local := NewTimestamp(2020, 8, 30, 15, 33, 14, 0, TZLocal())
+	if _, err := local.EncodeSortKey(true); !errors.Is(err, ErrorUnresolvableTimezone) {
+		t.Errorf("Expected encoding sort key for %v to fail with %v but got %v", local, ErrorUnresolvableTimezone, err)
+	}
+
+	key := encodeSortKey(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 0, TZAtAreaLocation("Asia/Tokyo")), true)
+	if _, err := DecodeSortKey(key[:SortKeyLength-1]); !errors.Is(err, ErrorIncomplete) {
+		t.Errorf("Expected truncated sort key to fail with %v but got %v", ErrorIncomplete, err)
+	}
+	if _, err := DecodeSortKey(key[:len(key)-2]); !errors.Is(err, ErrorIncomplete) {
+		t.Errorf("Expected truncated time zone to fail with %v but got %v", ErrorIncomplete, err)
+	}
+	if _, err := DecodeSortKey(key[:len(key)-1]); !errors.Is(err, ErrorIncomplete) {
+		t.Errorf("Expected missing precision to fail with %v but got %v", ErrorIncomplete, err)
+	}
+	if _, err := DecodeSortKey(append(key, 0)); err == nil {
+		t.Errorf("Expected trailing data after sort key to fail")
+	}
+	for _, precision := range []Precision{PrecisionNanoseconds + 1, 0xff} {
+		badPrecision := append([]byte{}, key...)
+		badPrecision[len(badPrecision)-1] = byte(precision)
+		if _, err := DecodeSortKey(badPrecision); err == nil {
+			t.Errorf("Expected sort key with precision %v to fail", precision)
+		}
+	}
+	tooPrecise := encodeSortKey(t, NewTimestamp(2020, 1, 1, 0, 0, 0, 1000, TZAtUTC()), true)
+	tooPrecise[len(tooPrecise)-1] = byte(PrecisionMilliseconds)
+	if _, err := DecodeSortKey(tooPrecise); err == nil {
+		t.Errorf("Expected sort key with more subseconds than its precision to fail")
+	}
+	key[7] = 0xff
+	if _, err := DecodeSortKey(key); err == nil {
+		t.Errorf("Expected sort key with invalid fields to fail")
+	}
+}
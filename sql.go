@@ -0,0 +1,99 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"database/sql/driver"
+	"fmt"
+	gotime "time"
+)
+
+// Scan a database value. This implements sql.Scanner, and accepts:
+//  * []byte in binary form (see MarshalBinary) or text form
+//  * string in text form (see MarshalText)
+//  * time.Time, which is converted using AsCompactTime
+func (this *Time) Scan(src interface{}) (err error) {
+	switch v := src.(type) {
+	case []byte:
+		// Binary form starts with the time type, which is never printable
+		if len(v) > 0 && v[0] <= byte(TimeTypeTimestamp) {
+			return this.UnmarshalBinary(v)
+		}
+		return this.UnmarshalText(v)
+	case string:
+		return this.UnmarshalText([]byte(v))
+	case gotime.Time:
+		*this, err = AsCompactTime(v)
+		return
+	case nil:
+		return fmt.Errorf("Cannot scan NULL into a time (use NullTime instead)")
+	default:
+		return fmt.Errorf("%T: Cannot scan this type into a time", src)
+	}
+}
+
+// Get the database value of this time, in text form (see MarshalText). This
+// implements driver.Valuer. Use SQLBinaryTime to store the binary form instead.
+func (this Time) Value() (driver.Value, error) {
+	text, err := this.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return string(text), nil
+}
+
+// A time that is stored in the database in binary form (see MarshalBinary).
+// For a nullable column, scan into and store a *SQLBinaryTime.
+type SQLBinaryTime Time
+
+func (this *SQLBinaryTime) Scan(src interface{}) error {
+	return (*Time)(this).Scan(src)
+}
+
+func (this SQLBinaryTime) Value() (driver.Value, error) {
+	return Time(this).MarshalBinary()
+}
+
+// A time that may be NULL in the database. It is stored in text form.
+type NullTime struct {
+	Time  Time
+	Valid bool // Valid is true if Time is not NULL
+}
+
+func (this *NullTime) Scan(src interface{}) error {
+	if src == nil {
+		this.Time, this.Valid = Time{}, false
+		return nil
+	}
+	if err := this.Time.Scan(src); err != nil {
+		this.Valid = false
+		return err
+	}
+	this.Valid = true
+	return nil
+}
+
+func (this NullTime) Value() (driver.Value, error) {
+	if !this.Valid {
+		return nil, nil
+	}
+	return this.Time.Value()
+}
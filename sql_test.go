@@ -0,0 +1,103 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	gotime "time"
+)
+
+var (
+	_ sql.Scanner   = (*Time)(nil)
+	_ driver.Valuer = Time{}
+	_ sql.Scanner   = (*SQLBinaryTime)(nil)
+	_ driver.Valuer = SQLBinaryTime{}
+	_ sql.Scanner   = (*NullTime)(nil)
+	_ driver.Valuer = NullTime{}
+)
+
+func assertScan(t *testing.T, src interface{}, expected Time) {
+	var actual Time
+	if err := actual.Scan(src); err != nil {
+		t.Errorf("Error scanning %v: %v", src, err)
+		return
+	}
+	if actual.Type != expected.Type || !expected.IsEquivalentTo(actual) {
+		t.Errorf("Expected %v to scan to %v but got %v", src, expected, actual)
+	}
+}
+
+func assertScanError(t *testing.T, src interface{}) {
+	var actual Time
+	if err := actual.Scan(src); err == nil {
+		t.Errorf("Expected scanning %v to fail but got %v", src, actual)
+	}
+}
+
+func assertValueRoundTrip(t *testing.T, valuer driver.Valuer, expected Time) {
+	value, err := valuer.Value()
+	if err != nil {
+		t.Errorf("Error getting database value of %v: %v", expected, err)
+		return
+	}
+	if !driver.IsValue(value) {
+		t.Errorf("%v: %T is not a valid driver value", expected, value)
+		return
+	}
+	assertScan(t, value, expected)
+}
+
+func TestSQL(t *testing.T) {
+	assertScan(t, "2020-01-15", NewDate(2020, 1, 15))
+	assertScan(t, []byte("13:41:00/E/Berlin"), NewTime(13, 41, 0, 0, TZAtAreaLocation("Europe/Berlin")))
+	assertScan(t, []byte{0x02, 0x28, 0x9a, 0x12, 0x78, 0x08}, NewTimestamp(1966, 12, 1, 5, 13, 5, 0, TZAtUTC()))
+	assertScan(t, gotime.Date(2020, 1, 15, 13, 41, 0, 0, gotime.UTC), NewTimestamp(2020, 1, 15, 13, 41, 0, 0, TZAtUTC()))
+	assertScanError(t, nil)
+	assertScanError(t, 1234)
+	assertScanError(t, "not a time")
+	assertScanError(t, []byte{0x02, 0x28})
+
+	for _, value := range marshalTestValues {
+		assertValueRoundTrip(t, value, value)
+		assertValueRoundTrip(t, SQLBinaryTime(value), value)
+		assertValueRoundTrip(t, NullTime{Time: value, Valid: true}, value)
+	}
+
+	binaryValue, err := SQLBinaryTime(NewDate(2000, 1, 1)).Value()
+	if err != nil {
+		t.Errorf("Error getting binary database value: %v", err)
+	} else if _, ok := binaryValue.([]byte); !ok {
+		t.Errorf("Expected binary database value to be []byte but got %T", binaryValue)
+	}
+
+	var nullTime NullTime
+	if err = nullTime.Scan(nil); err != nil || nullTime.Valid {
+		t.Errorf("Expected scanning NULL to give an invalid NullTime but got %v, %v", nullTime, err)
+	}
+	if value, err := nullTime.Value(); err != nil || value != nil {
+		t.Errorf("Expected invalid NullTime to have a NULL value but got %v, %v", value, err)
+	}
+	if err = nullTime.Scan("2020-01-15"); err != nil || !nullTime.Valid || !nullTime.Time.IsEquivalentTo(NewDate(2020, 1, 15)) {
+		t.Errorf("Expected scanning a date to give a valid NullTime but got %v, %v", nullTime, err)
+	}
+}
@@ -0,0 +1,256 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/kstenerud/go-uleb128"
+)
+
+// Streams can optionally use a time zone dictionary to avoid repeating the
+// same area/location strings. When enabled, every area/location that is
+// written out in full is assigned the next index (starting at 0). Later
+// occurrences are encoded as a string consisting of a 0 byte followed by the
+// ULEB128 index, unless that wouldn't be any shorter. Area/location strings
+// never contain a 0 byte, so each value's layout remains spec compliant, but
+// the stream must be read back by a Decoder that also has the dictionary
+// enabled.
+//
+// UTC and local aliases such as "Z" and "L" are not added to the dictionary,
+// and once the dictionary holds maxTimezoneDictionarySize entries, no more
+// indices are assigned.
+const maxTimezoneDictionarySize = 1 << 16
+
+const timezoneReferenceMarker = 0
+
+// Encoder writes a sequence of compact time values to a writer, reusing an
+// internal buffer so that encoding a value doesn't allocate.
+type Encoder struct {
+	writer         io.Writer
+	buffer         []byte
+	dictionary     map[string]int
+	dictionarySize int
+	useDictionary  bool
+	bytesEncoded   int64
+}
+
+func NewEncoder(writer io.Writer) *Encoder {
+	this := &Encoder{}
+	this.Init(writer)
+	return this
+}
+
+// Create an encoder that uses a time zone dictionary. The values it produces
+// must be read using a decoder from NewTimezoneDictionaryDecoder.
+func NewTimezoneDictionaryEncoder(writer io.Writer) *Encoder {
+	this := &Encoder{useDictionary: true}
+	this.Init(writer)
+	return this
+}
+
+// Init (or reset) this encoder to write to a new writer. The time zone
+// dictionary (if enabled) is cleared.
+func (this *Encoder) Init(writer io.Writer) {
+	this.writer = writer
+	if this.buffer == nil {
+		this.buffer = make([]byte, 0, MaxEncodeLength)
+	}
+	if this.useDictionary {
+		this.dictionary = make(map[string]int)
+	}
+	this.dictionarySize = 0
+	this.bytesEncoded = 0
+}
+
+// Encode a time value (date, time, or timestamp).
+func (this *Encoder) Encode(time *Time) (err error) {
+	if this.buffer, err = time.AppendEncoded(this.buffer[:0]); err != nil {
+		return
+	}
+	if this.useDictionary && time.Type != TimeTypeDate && !time.IsZeroValue() {
+		switch time.Timezone.Type {
+		case TimezoneTypeAreaLocation, TimezoneTypeLocal:
+			if isDictionaryAreaLocation(time.Timezone.ShortAreaLocation) {
+				this.applyDictionary(time.Timezone.ShortAreaLocation)
+			}
+		}
+	}
+	var bytesEncoded int
+	bytesEncoded, err = this.writer.Write(this.buffer)
+	this.bytesEncoded += int64(bytesEncoded)
+	return
+}
+
+// Get the total number of bytes written so far.
+func (this *Encoder) BytesEncoded() int64 {
+	return this.bytesEncoded
+}
+
+// The area/location string is always at the end of the encoded value, so it
+// can be swapped for a reference in place.
+func (this *Encoder) applyDictionary(areaLocation string) {
+	var reference [11]byte
+	reference[0] = timezoneReferenceMarker
+	referenceLength := 0
+	if index, isKnown := this.dictionary[areaLocation]; isKnown {
+		referenceLength = 1 + uleb128.EncodeUint64ToBytes(uint64(index), reference[1:])
+	}
+
+	if referenceLength == 0 || referenceLength >= len(areaLocation) {
+		// Every literal gets a new index (even if it's a repeat) so that the
+		// decoder doesn't need to look anything up.
+		if this.dictionarySize < maxTimezoneDictionarySize {
+			this.dictionary[areaLocation] = this.dictionarySize
+			this.dictionarySize++
+		}
+		return
+	}
+
+	this.buffer = this.buffer[:len(this.buffer)-len(areaLocation)-1]
+	this.buffer = append(this.buffer, byte(referenceLength<<shiftLength))
+	this.buffer = append(this.buffer, reference[:referenceLength]...)
+}
+
+// Check if an encoded area/location string takes part in the dictionary. The
+// encoder and decoder must agree exactly, so this depends only on the string
+// as written: UTC and local aliases are skipped, whatever time zone type they
+// were encoded from.
+func isDictionaryAreaLocation(areaLocation string) bool {
+	_, isAlias := areaLocationToTimezoneType[areaLocation]
+	return !isAlias
+}
+
+// Decoder reads a sequence of compact time values from a reader. It buffers
+// the reader and reuses its scratch buffer, so decoding a value only allocates
+// when it has an area/location time zone.
+//
+// When there are no more values, the Next methods return io.EOF. A value that
+// is cut short returns a DecodeError whose offset is relative to the start of
+// that value, which itself begins at the BytesDecoded() count from before the
+// call.
+type Decoder struct {
+	reader        *bufio.Reader
+	buffer        []byte
+	dictionary    []Timezone
+	useDictionary bool
+	bytesDecoded  int64
+}
+
+func NewDecoder(reader io.Reader) *Decoder {
+	this := &Decoder{}
+	this.Init(reader)
+	return this
+}
+
+// Create a decoder for values written by an encoder from
+// NewTimezoneDictionaryEncoder.
+func NewTimezoneDictionaryDecoder(reader io.Reader) *Decoder {
+	this := &Decoder{useDictionary: true}
+	this.Init(reader)
+	return this
+}
+
+// Init (or reset) this decoder to read from a new reader. The internal buffers
+// are kept for reuse, and the time zone dictionary (if enabled) is cleared.
+func (this *Decoder) Init(reader io.Reader) {
+	if this.reader == nil {
+		this.reader = bufio.NewReader(reader)
+		this.buffer = makeRequiredBuffer()
+	} else {
+		this.reader.Reset(reader)
+	}
+	this.dictionary = this.dictionary[:0]
+	this.bytesDecoded = 0
+}
+
+// Decode the next value as a date.
+func (this *Decoder) NextDate() (time Time, err error) {
+	var bytesDecoded int
+	time, bytesDecoded, err = DecodeDateWithBuffer(this.reader, this.buffer)
+	this.bytesDecoded += int64(bytesDecoded)
+	return
+}
+
+// Decode the next value as a time.
+func (this *Decoder) NextTime() (time Time, err error) {
+	var bytesDecoded int
+	time, bytesDecoded, err = DecodeTimeWithBuffer(this.reader, this.buffer)
+	this.bytesDecoded += int64(bytesDecoded)
+	if err == nil && this.useDictionary {
+		err = this.applyDictionary(&time.Timezone, bytesDecoded)
+	}
+	return
+}
+
+// Decode the next value as a timestamp.
+func (this *Decoder) NextTimestamp() (time Time, err error) {
+	var bytesDecoded int
+	time, bytesDecoded, err = DecodeTimestampWithBuffer(this.reader, this.buffer)
+	this.bytesDecoded += int64(bytesDecoded)
+	if err == nil && this.useDictionary {
+		err = this.applyDictionary(&time.Timezone, bytesDecoded)
+	}
+	return
+}
+
+// Get the total number of bytes consumed by all values decoded so far,
+// including any partial value that failed to decode.
+func (this *Decoder) BytesDecoded() int64 {
+	return this.bytesDecoded
+}
+
+func (this *Decoder) applyDictionary(tz *Timezone, valueByteCount int) error {
+	// Only strings that pass isDictionaryAreaLocation decode to an
+	// area/location time zone.
+	if tz.Type != TimezoneTypeAreaLocation {
+		return nil
+	}
+	areaLocation := tz.ShortAreaLocation
+	if areaLocation[0] != timezoneReferenceMarker {
+		if len(this.dictionary) < maxTimezoneDictionarySize {
+			this.dictionary = append(this.dictionary, *tz)
+		}
+		return nil
+	}
+
+	index := 0
+	shift := uint(0)
+	for i := 1; i < len(areaLocation) && shift < 28; i++ {
+		group := areaLocation[i]
+		index |= int(group&0x7f) << shift
+		shift += 7
+		if group&0x80 == 0 {
+			if i == len(areaLocation)-1 && index < len(this.dictionary) {
+				*tz = this.dictionary[index]
+				return nil
+			}
+			break
+		}
+	}
+	return &DecodeError{
+		Field:  DecodeFieldTimezone,
+		Offset: valueByteCount - len(areaLocation) - 1,
+		Err:    fmt.Errorf("%x: Invalid time zone dictionary reference", areaLocation[1:]),
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2019 Karl Stenerud
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package compact_time
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+type countingReader struct {
+	reader    io.Reader
+	readCount int
+}
+
+func (this *countingReader) Read(p []byte) (n int, err error) {
+	this.readCount++
+	return this.reader.Read(p)
+}
+
+func encodeAll(t testing.TB, values ...Time) []byte {
+	var encoded []byte
+	var err error
+	for _, value := range values {
+		if encoded, err = value.AppendEncoded(encoded); err != nil {
+			t.Fatalf("Error encoding %v: %v", value, err)
+		}
+	}
+	return encoded
+}
+
+func decoderNext(decoder *Decoder, timeType TimeType) (Time, error) {
+	switch timeType {
+	case TimeTypeDate:
+		return decoder.NextDate()
+	case TimeTypeTime:
+		return decoder.NextTime()
+	default:
+		return decoder.NextTimestamp()
+	}
+}
+
+func TestDecoderSequence(t *testing.T) {
+	values := []Time{
+		NewDate(2020, 8, 30),
+		NewTime(13, 41, 5, 999999999, TZWithMiutesOffsetFromUTC(-330)),
+		NewTimestamp(2020, 8, 30, 15, 33, 14, 19577323, TZAtAreaLocation("Asia/Singapore")),
+		ZeroTimestamp(),
+		NewTimestamp(-50000, 1, 1, 0, 0, 0, 0, TZAtLatLong(5994, 1071)),
+		NewTime(23, 59, 60, 0, TZAtUTC()),
+	}
+	encoded := encodeAll(t, values...)
+	decoder := NewDecoder(bytes.NewBuffer(encoded))
+	expectedCount := int64(0)
+	for _, expected := range values {
+		actual, err := decoderNext(decoder, expected.Type)
+		if err != nil {
+			t.Fatalf("Error decoding %v: %v", expected, err)
+		}
+		if !expected.IsEquivalentTo(actual) {
+			t.Errorf("Expected %v but got %v", expected, actual)
+		}
+		expectedCount += int64(expected.EncodedSize())
+		if actualCount := decoder.BytesDecoded(); actualCount != expectedCount {
+			t.Errorf("Expected %v bytes decoded after %v but got %v", expectedCount, expected, actualCount)
+		}
+	}
+	if _, err := decoder.NextTimestamp(); err != io.EOF {
+		t.Errorf("Expected io.EOF at end of data but got %v", err)
+	}
+}
+
+func TestDecoderIncomplete(t *testing.T) {
+	encoded := encodeAll(t, NewDate(2020, 8, 30), NewTimestamp(2020, 8, 30, 15, 33, 14, 0, TZAtUTC()))
+	decoder := NewDecoder(bytes.NewBuffer(encoded[:len(encoded)-1]))
+	if _, err := decoder.NextDate(); err != nil {
+		t.Fatalf("Error decoding date: %v", err)
+	}
+	_, err := decoder.NextTimestamp()
+	if !errors.Is(err, ErrorIncomplete) {
+		t.Errorf("Expected truncated timestamp to fail with %v but got %v", ErrorIncomplete, err)
+	}
+	if expected, actual := int64(len(encoded)-1), decoder.BytesDecoded(); actual != expected {
+		t.Errorf("Expected %v bytes decoded but got %v", expected, actual)
+	}
+}
+
+func TestDecoderBuffering(t *testing.T) {
+	values := make([]Time, 1000)
+	for i := range values {
+		values[i] = NewTimestamp(2020, 8, 30, 15, 33, i%60, 19577323, TZWithMiutesOffsetFromUTC(540))
+	}
+	reader := &countingReader{reader: bytes.NewBuffer(encodeAll(t, values...))}
+	decoder := NewDecoder(reader)
+	for range values {
+		if _, err := decoder.NextTimestamp(); err != nil {
+			t.Fatalf("Error decoding: %v", err)
+		}
+	}
+	if reader.readCount > len(values)/100 {
+		t.Errorf("Expected buffered reads but the reader was called %v times for %v values", reader.readCount, len(values))
+	}
+
+	decoder = NewDecoder(bytes.NewBuffer(encodeAll(t, values...)))
+	allocs := testing.AllocsPerRun(500, func() {
+		decoder.NextTimestamp()
+	})
+	if allocs != 0 {
+		t.Errorf("Expected decoding to make no allocations but got %v", allocs)
+	}
+}
+
+func BenchmarkDecoderNextTimestamp(b *testing.B) {
+	values := make([]Time, 1000)
+	for i := range values {
+		values[i] = NewTimestamp(2020, 8, 30, 15, 33, i%60, 19577323, TZAtUTC())
+	}
+	encoded := encodeAll(b, values...)
+	reader := bytes.NewReader(encoded)
+	decoder := NewDecoder(reader)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := decoder.NextTimestamp(); err != nil {
+			reader.Reset(encoded)
+			decoder.Init(reader)
+		}
+	}
+}
+
+func encodeStream(t *testing.T, encoder *Encoder, values []Time) {
+	for _, value := range values {
+		if err := encoder.Encode(&value); err != nil {
+			t.Fatalf("Error encoding %v: %v", value, err)
+		}
+	}
+}
+
+func assertDecodeStream(t *testing.T, decoder *Decoder, values []Time) {
+	for _, expected := range values {
+		actual, err := decoderNext(decoder, expected.Type)
+		if err != nil {
+			t.Fatalf("Error decoding %v: %v", expected, err)
+		}
+		if !expected.IsEquivalentTo(actual) {
+			t.Errorf("Expected %v but got %v", expected, actual)
+		}
+	}
+	if _, err := decoder.NextTimestamp(); err != io.EOF {
+		t.Errorf("Expected io.EOF at end of data but got %v", err)
+	}
+}
+
+var timezoneDictionaryTestValues = []Time{
+	NewTimestamp(2020, 8, 30, 15, 33, 14, 0, TZAtAreaLocation("America/New_York")),
+	NewTimestamp(2020, 8, 30, 15, 33, 15, 0, TZAtAreaLocation("America/New_York")),
+	NewDate(2020, 8, 30),
+	NewTime(10, 0, 0, 0, TZAtAreaLocation("Asia/Tokyo")),
+	NewTime(10, 0, 1, 0, TZLocal()),
+	NewTimestamp(2020, 8, 30, 15, 33, 16, 0, TZAtAreaLocation("X")),
+	NewTimestamp(2020, 8, 30, 15, 33, 16, 0, TZAtAreaLocation("X")),
+	NewTime(10, 0, 2, 0, TZAtAreaLocation("Asia/Tokyo")),
+	NewTimestamp(2020, 8, 30, 15, 33, 17, 0, TZAtAreaLocation("Etc/GMT")),
+	NewTimestamp(2020, 8, 30, 15, 33, 18, 0, TZAtAreaLocation("America/New_York")),
+	ZeroTimestamp(),
+}
+
+func TestTimezoneDictionary(t *testing.T) {
+	plain := &bytes.Buffer{}
+	plainEncoder := NewEncoder(plain)
+	encodeStream(t, plainEncoder, timezoneDictionaryTestValues)
+	if expected := int64(len(encodeAll(t, timezoneDictionaryTestValues...))); plainEncoder.BytesEncoded() != expected {
+		t.Errorf("Expected plain encoder to write %v bytes but got %v", expected, plainEncoder.BytesEncoded())
+	}
+	assertDecodeStream(t, NewDecoder(plain), timezoneDictionaryTestValues)
+
+	compressed := &bytes.Buffer{}
+	encoder := NewTimezoneDictionaryEncoder(compressed)
+	encodeStream(t, encoder, timezoneDictionaryTestValues)
+	if encoder.BytesEncoded() != int64(compressed.Len()) {
+		t.Errorf("Expected %v bytes encoded but got %v", compressed.Len(), encoder.BytesEncoded())
+	}
+	if expected := plainEncoder.BytesEncoded() - int64(2*(len("A/New_York")-2)+len("S/Tokyo")-2); encoder.BytesEncoded() != expected {
+		t.Errorf("Expected dictionary encoding to take %v bytes but got %v", expected, encoder.BytesEncoded())
+	}
+	assertDecodeStream(t, NewTimezoneDictionaryDecoder(compressed), timezoneDictionaryTestValues)
+
+	// Reusing the encoder and decoder must start a fresh dictionary
+	compressed.Reset()
+	encoder.Init(compressed)
+	encodeStream(t, encoder, timezoneDictionaryTestValues[1:])
+	decoder := NewTimezoneDictionaryDecoder(bytes.NewBuffer(nil))
+	decoder.Init(compressed)
+	assertDecodeStream(t, decoder, timezoneDictionaryTestValues[1:])
+}
+
+func TestTimezoneDictionaryAliasAsAreaLocation(t *testing.T) {
+	// An area/location time zone holding an alias is written as the alias,
+	// so it must not take up a dictionary index.
+	alias := NewTimestamp(2020, 8, 30, 15, 33, 14, 0, Timezone{
+		Type:              TimezoneTypeAreaLocation,
+		ShortAreaLocation: "Z",
+		LongAreaLocation:  "Z",
+	})
+	values := []Time{
+		NewTimestamp(2020, 8, 30, 15, 33, 15, 0, TZAtAreaLocation("America/New_York")),
+		NewTimestamp(2020, 8, 30, 15, 33, 16, 0, TZAtAreaLocation("America/New_York")),
+	}
+	compressed := &bytes.Buffer{}
+	encoder := NewTimezoneDictionaryEncoder(compressed)
+	encodeStream(t, encoder, append([]Time{alias}, values...))
+
+	decoder := NewTimezoneDictionaryDecoder(compressed)
+	actual, err := decoder.NextTimestamp()
+	if err != nil {
+		t.Fatalf("Error decoding %v: %v", alias, err)
+	}
+	if expected := NewTimestamp(2020, 8, 30, 15, 33, 14, 0, TZAtUTC()); !expected.IsEquivalentTo(actual) {
+		t.Errorf("Expected %v but got %v", expected, actual)
+	}
+	assertDecodeStream(t, decoder, values)
+}
+
+func TestTimezoneDictionaryBadReference(t *testing.T) {
+	value := NewTime(10, 0, 0, 0, TZAtAreaLocation("Asia/Tokyo"))
+	encoded := encodeAll(t, value)
+	encoded = append(encoded[:len(encoded)-len("S/Tokyo")-1], 0x04, 0x00, 0x05)
+	_, err := NewTimezoneDictionaryDecoder(bytes.NewBuffer(encoded)).NextTime()
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) || decodeErr.Field != DecodeFieldTimezone || decodeErr.Offset != len(encoded)-3 {
+		t.Errorf("Expected a time zone DecodeError at offset %v but got %v", len(encoded)-3, err)
+	}
+}
+
+func TestEncoderAllocations(t *testing.T) {
+	encoder := NewTimezoneDictionaryEncoder(ioutil.Discard)
+	value := NewTimestamp(2020, 8, 30, 15, 33, 14, 0, TZAtAreaLocation("America/New_York"))
+	allocs := testing.AllocsPerRun(100, func() {
+		encoder.Encode(&value)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected encoding to make no allocations but got %v", allocs)
+	}
+}
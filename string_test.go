@@ -40,4 +40,10 @@ func TestStringRepresentation(t *testing.T) {
 	assertStringRep(t, NewTimestamp(2020, 1, 15, 13, 41, 0, 599000, TZAtLatLong(500, -500)), "2020-01-15/13:41:00.000599/5.00/-5.00")
 	assertStringRep(t, NewTimestamp(2020, 1, 15, 13, 41, 0, 599000, TZWithMiutesOffsetFromUTC(60)), "2020-01-15/13:41:00.000599+0100")
 	assertStringRep(t, NewTimestamp(2020, 1, 15, 13, 41, 0, 599000, TZWithMiutesOffsetFromUTC(-1)), "2020-01-15/13:41:00.000599-0001")
+
+	assertStringRep(t, withPrecision(NewTime(12, 0, 0, 100000000, TZAtUTC()), PrecisionMilliseconds), "12:00:00.100")
+	assertStringRep(t, withPrecision(NewTime(12, 0, 0, 0, TZAtUTC()), PrecisionMilliseconds), "12:00:00.000")
+	assertStringRep(t, withPrecision(NewTime(12, 0, 0, 100000000, TZAtUTC()), PrecisionNanoseconds), "12:00:00.100000000")
+	assertStringRep(t, withPrecision(NewTimestamp(2020, 1, 15, 13, 41, 0, 0, TZLocal()), PrecisionSeconds), "2020-01-15/13:41:00/Local")
+	assertStringRep(t, withPrecision(NewTime(12, 0, 0, 100000000, TZAtUTC()), 7), "12:00:00.100000000")
 }
@@ -23,6 +23,7 @@ package compact_time
 import (
 	"fmt"
 	"strings"
+	"sync"
 	gotime "time"
 )
 
@@ -188,6 +189,19 @@ func (this *Timezone) String() string {
 	}
 }
 
+// The declared precision of a time's subseconds, which determines the
+// subsecond magnitude it's encoded with.
+type Precision uint8
+
+const (
+	// Use the coarsest precision that can hold the nanosecond value
+	PrecisionUnspecified Precision = iota
+	PrecisionSeconds
+	PrecisionMilliseconds
+	PrecisionMicroseconds
+	PrecisionNanoseconds
+)
+
 type Time struct {
 	Timezone   Timezone
 	Year       int
@@ -198,6 +212,9 @@ type Time struct {
 	Day        uint8
 	Month      uint8
 	Type       TimeType
+	// Precision is filled in when decoding times and timestamps, and is
+	// otherwise optional. It's ignored for dates.
+	Precision Precision
 }
 
 // Create a "zero" date, which will encode to all zeroes.
@@ -227,6 +244,7 @@ func (this *Time) InitDate(year, month, day int) {
 	this.Month = uint8(month)
 	this.Day = uint8(day)
 	this.Timezone.Type = TimezoneTypeLocal
+	this.Precision = PrecisionUnspecified
 }
 
 func NewTime(hour, minute, second, nanosecond int, timezone Timezone) Time {
@@ -242,6 +260,7 @@ func (this *Time) InitTime(hour, minute, second, nanosecond int, timezone Timezo
 	this.Second = uint8(second)
 	this.Nanosecond = uint32(nanosecond)
 	this.Timezone = timezone
+	this.Precision = PrecisionUnspecified
 }
 
 func NewTimestamp(year, month, day, hour, minute, second, nanosecond int, timezone Timezone) Time {
@@ -260,6 +279,7 @@ func (this *Time) InitTimestamp(year, month, day, hour, minute, second, nanoseco
 	this.Nanosecond = uint32(nanosecond)
 	this.Timezone = tz
 	this.Type = TimeTypeTimestamp
+	this.Precision = PrecisionUnspecified
 }
 
 func (this *Time) IsZeroValue() bool {
@@ -267,8 +287,13 @@ func (this *Time) IsZeroValue() bool {
 }
 
 // Check if two times are equivalent. This handles cases where the time zones
-// are technically equivalent (Z == UTC == Etc/UTC == Etc/GMT, etc)
+// are technically equivalent (Z == UTC == Etc/UTC == Etc/GMT, etc), and where
+// one precision is unspecified but works out to be the same as the other.
 func (this *Time) IsEquivalentTo(that Time) bool {
+	if this.Type != TimeTypeDate && this.subsecondMagnitude() != that.subsecondMagnitude() {
+		return false
+	}
+	that.Precision = this.Precision
 	if this.Timezone.Type == TimezoneTypeUTC && that.Timezone.Type == TimezoneTypeUTC {
 		return this.Year == that.Year &&
 			this.Month == that.Month &&
@@ -281,39 +306,34 @@ func (this *Time) IsEquivalentTo(that Time) bool {
 	return *this == that
 }
 
-// Convert a golang time value to compact time
-func AsCompactTime(src gotime.Time) Time {
-	locationStr := src.Location().String()
-	if src.Location() == gotime.Local {
-		locationStr = "Local"
-	}
+// Returned (wrapped) when a go time's location has a name that is neither a
+// fixed UTC offset nor a valid IANA area/location.
+var ErrorInvalidLocationName = fmt.Errorf("Location name is not a valid IANA time zone")
+
+// Convert a golang time value to compact time.
+//
+// Fixed offset locations (such as those from time.FixedZone or a parsed
+// "+08:00") are converted to UTC offset time zones.
+//
+// Note: If the location's name is neither a fixed offset nor a valid IANA
+//       time zone, the returned time uses the UTC offset in effect at that
+//       time, and the returned error wraps ErrorInvalidLocationName.
+// Note: UTC offsets beyond +/- 23:59 can't be represented, and return an
+//       error.
+func AsCompactTime(src gotime.Time) (Time, error) {
+	tz, err := timezoneFromGoTime(src)
 	return NewTimestamp(src.Year(), int(src.Month()), src.Day(), src.Hour(),
-		src.Minute(), src.Second(), src.Nanosecond(), TZAtAreaLocation(locationStr))
+		src.Minute(), src.Second(), src.Nanosecond(), tz), err
 }
 
 // Convert compact time into golang time.
 // Note: Go time doesn't support latitude/longitude time zones. Attempting to
-//       convert this type of time zone will result in an error.
+//       convert this type of time zone will result in an error unless a
+//       resolver has been set using SetLatLongResolver.
 // Note: Converting to go time will validate area/location time zone (if any)
 func (this *Time) AsGoTime() (result gotime.Time, err error) {
-	location := gotime.UTC
-	switch this.Timezone.Type {
-	case TimezoneTypeUTC:
-		location = gotime.UTC
-	case TimezoneTypeLocal:
-		location = gotime.Local
-	case TimezoneTypeLatitudeLongitude:
-		err = fmt.Errorf("Latitude/Longitude time zones are not supported by time.Time")
-		return
-	case TimezoneTypeAreaLocation:
-		location, err = gotime.LoadLocation(this.Timezone.LongAreaLocation)
-		if err != nil {
-			return
-		}
-	case TimezoneTypeUTCOffset:
-		location = gotime.FixedZone("", int(this.Timezone.MinutesOffsetFromUTC)*60)
-	default:
-		err = fmt.Errorf("%v: Unknown time zone type", this.Timezone.Type)
+	var location *gotime.Location
+	if location, err = this.Timezone.goLocation(); err != nil {
 		return
 	}
 	result = gotime.Date(this.Year,
@@ -327,6 +347,38 @@ func (this *Time) AsGoTime() (result gotime.Time, err error) {
 	return
 }
 
+func (this *Timezone) goLocation() (location *gotime.Location, err error) {
+	switch this.Type {
+	case TimezoneTypeUTC:
+		location = gotime.UTC
+	case TimezoneTypeLocal:
+		location = gotime.Local
+	case TimezoneTypeLatitudeLongitude:
+		location, err = resolveLatLong(int(this.LatitudeHundredths), int(this.LongitudeHundredths))
+	case TimezoneTypeAreaLocation:
+		location, err = loadGoLocation(this.LongAreaLocation)
+	case TimezoneTypeUTCOffset:
+		location = gotime.FixedZone("", int(this.MinutesOffsetFromUTC)*60)
+	default:
+		err = fmt.Errorf("%v: Unknown time zone type", this.Type)
+	}
+	return
+}
+
+// Finds the time zone in effect at a latitude and longitude (in hundredths of
+// degrees).
+type LatLongResolver func(latitudeHundredths, longitudeHundredths int) (*gotime.Location, error)
+
+// Set the resolver used to convert latitude/longitude time zones (for example
+// in AsGoTime, Add, Compare, and In). Without a resolver (or after setting it
+// to nil), these conversions fail with an error that wraps
+// ErrorUnresolvableTimezone.
+func SetLatLongResolver(resolver LatLongResolver) {
+	latLongResolverMutex.Lock()
+	defer latLongResolverMutex.Unlock()
+	latLongResolver = resolver
+}
+
 func (this Time) String() string {
 	// Workaround for go's broken Stringer type handling
 	return this.pString()
@@ -334,7 +386,7 @@ func (this Time) String() string {
 
 func (this *Time) pString() string {
 	if this.IsZeroValue() {
-		return "<zero time value>"
+		return zeroValueString
 	}
 	switch this.Type {
 	case TimeTypeDate:
@@ -349,6 +401,10 @@ func (this *Time) pString() string {
 }
 
 func (this *Time) Validate() error {
+	if this.Precision > PrecisionNanoseconds {
+		return fmt.Errorf("%v: Invalid precision", this.Precision)
+	}
+
 	if this.Type == TimeTypeDate || this.Type == TimeTypeTimestamp {
 		if this.Year == 0 {
 			return fmt.Errorf("Year cannot be 0")
@@ -374,6 +430,9 @@ func (this *Time) Validate() error {
 		if this.Nanosecond < nanosecondMin || this.Nanosecond > nanosecondMax {
 			return fmt.Errorf("%v: Invalid nanosecond (must be %v to %v)", this.Nanosecond, nanosecondMin, nanosecondMax)
 		}
+		if this.Precision != PrecisionUnspecified && getSubsecondMagnitude(int(this.Nanosecond)) > this.subsecondMagnitude() {
+			return fmt.Errorf("%v: Nanosecond has more digits than the declared precision allows", this.Nanosecond)
+		}
 		return this.Timezone.Validate()
 	}
 
@@ -382,6 +441,103 @@ func (this *Time) Validate() error {
 
 // =============================================================================
 
+func timezoneFromGoTime(src gotime.Time) (tz Timezone, err error) {
+	location := src.Location()
+	switch location {
+	case gotime.UTC:
+		return TZAtUTC(), nil
+	case gotime.Local:
+		return TZLocal(), nil
+	}
+
+	name := location.String()
+	if !isFixedOffsetName(name) {
+		if _, isKnown := areaLocationToTimezoneType[name]; isKnown || isIANALocationName(name) {
+			return TZAtAreaLocation(name), nil
+		}
+		err = ErrorInvalidLocationName
+	}
+
+	_, offsetSeconds := src.Zone()
+	minutes := offsetSeconds / 60
+	if minutes < minutesFromUTCMin || minutes > minutesFromUTCMax {
+		err = fmt.Errorf("%v: UTC offset of %v minutes is out of range (must be %v to %v)", name, minutes, minutesFromUTCMin, minutesFromUTCMax)
+	} else if offsetSeconds%60 != 0 {
+		err = fmt.Errorf("%v: UTC offset of %v seconds is not a whole number of minutes", name, offsetSeconds)
+	} else if err != nil {
+		err = fmt.Errorf("%v: %w (using UTC offset of %v minutes instead)", name, err, minutes)
+	}
+	return TZWithMiutesOffsetFromUTC(minutes), err
+}
+
+// Returns true if the location name describes a fixed UTC offset, as is the
+// case for time.FixedZone("", ...), and for offsets parsed by time.Parse. For
+// example: "", "+08", "-0530", "+05:30", "UTC+8", "GMT-03:00".
+func isFixedOffsetName(name string) bool {
+	if name == "" {
+		return true
+	}
+	if strings.HasPrefix(name, "UTC") || strings.HasPrefix(name, "GMT") {
+		name = name[3:]
+	}
+	if len(name) < 2 || (name[0] != '+' && name[0] != '-') {
+		return false
+	}
+	name = name[1:]
+	digitCount := 0
+	for i := 0; i < len(name); i++ {
+		switch {
+		case isDigit(name[i]):
+			digitCount++
+		case name[i] == ':' && i == 2:
+		default:
+			return false
+		}
+	}
+	return digitCount >= 1 && digitCount <= 4
+}
+
+func isIANALocationName(name string) bool {
+	_, err := loadGoLocation(name)
+	return err == nil
+}
+
+var latLongResolver LatLongResolver
+var latLongResolverMutex sync.RWMutex
+
+func resolveLatLong(latitudeHundredths, longitudeHundredths int) (location *gotime.Location, err error) {
+	latLongResolverMutex.RLock()
+	resolver := latLongResolver
+	latLongResolverMutex.RUnlock()
+	tz := TZAtLatLong(latitudeHundredths, longitudeHundredths)
+	if resolver == nil {
+		err = fmt.Errorf("%v: %w (no latitude/longitude resolver is set)", tz.String(), ErrorUnresolvableTimezone)
+		return
+	}
+	if location, err = resolver(latitudeHundredths, longitudeHundredths); err == nil && location == nil {
+		err = fmt.Errorf("%v: %w (resolver returned no location)", tz.String(), ErrorUnresolvableTimezone)
+	}
+	return
+}
+
+var goLocations sync.Map
+
+// Load a go location, caching it since loading is expensive. Failed lookups
+// aren't cached, since names can come from untrusted data and the cache would
+// otherwise grow with every distinct name seen. Successful ones are bounded
+// by the size of the time zone database.
+func loadGoLocation(name string) (*gotime.Location, error) {
+	if location, ok := goLocations.Load(name); ok {
+		return location.(*gotime.Location), nil
+	}
+	location, err := gotime.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	goLocations.Store(name, location)
+	return location, nil
+}
+
 func splitAreaLocation(areaLocation string) (shortAreaLocation, longAreaLocation string) {
 	longAreaLocation = areaLocation
 	tzPair := strings.SplitN(areaLocation, "/", 2)
@@ -415,18 +571,49 @@ func (this *Time) formatDate() string {
 func (this *Time) formatTime() string {
 	var builder strings.Builder
 	builder.WriteString(fmt.Sprintf("%02d:%02d:%02d", this.Hour, this.Minute, this.Second))
-	if this.Nanosecond != 0 {
-		str := []byte(fmt.Sprintf("%09d", this.Nanosecond))
-		for str[len(str)-1] == '0' {
-			str = str[:len(str)-1]
-		}
-		builder.WriteByte('.')
-		builder.WriteString(string(str))
-	}
+	builder.WriteString(formatSubseconds(this.Nanosecond, this.Precision))
 	builder.WriteString(this.Timezone.String())
 	return builder.String()
 }
 
+// Format subseconds as a decimal point followed by the significant digits, or
+// an empty string if there are no subseconds. If the precision is specified,
+// its number of digits is always printed instead.
+func formatSubseconds(nanosecond uint32, precision Precision) string {
+	if precision != PrecisionUnspecified {
+		if precision == PrecisionSeconds {
+			return ""
+		}
+		return fmt.Sprintf(".%09d", nanosecond)[:1+precision.magnitude()*3]
+	}
+	if nanosecond == 0 {
+		return ""
+	}
+	str := []byte(fmt.Sprintf(".%09d", nanosecond))
+	for str[len(str)-1] == '0' {
+		str = str[:len(str)-1]
+	}
+	return string(str)
+}
+
+// Get the subsecond magnitude (0-3) that this time encodes with.
+func (this *Time) subsecondMagnitude() int {
+	if this.Precision != PrecisionUnspecified {
+		return this.Precision.magnitude()
+	}
+	return getSubsecondMagnitude(int(this.Nanosecond))
+}
+
+// Get the subsecond magnitude of a specified precision. Unknown precisions
+// (which Validate rejects) are treated as nanoseconds so that they can still
+// be printed.
+func (this Precision) magnitude() int {
+	if this > PrecisionNanoseconds {
+		return int(PrecisionNanoseconds - PrecisionSeconds)
+	}
+	return int(this - PrecisionSeconds)
+}
+
 func (this *Time) formatTimestamp() string {
 	var builder strings.Builder
 	builder.WriteString(this.formatDate())
@@ -21,7 +21,9 @@
 package compact_time
 
 import (
+	"errors"
 	"testing"
+	gotime "time"
 )
 
 func assertEquivalentTime(t *testing.T, a, b Time) {
@@ -49,6 +51,13 @@ func assertInvalid(t *testing.T, a Time) {
 }
 
 func TestEquivalence(t *testing.T) {
+	assertEquivalentTime(t, withPrecision(NewTime(12, 1, 4, 91, TZAtUTC()), PrecisionNanoseconds), NewTime(12, 1, 4, 91, TZAtUTC()))
+	assertEquivalentTime(t, withPrecision(NewTime(12, 1, 4, 0, TZLocal()), PrecisionSeconds), NewTime(12, 1, 4, 0, TZLocal()))
+	assertNotEquivalentTime(t, withPrecision(NewTime(12, 1, 4, 0, TZLocal()), PrecisionMilliseconds), NewTime(12, 1, 4, 0, TZLocal()))
+	assertNotEquivalentTime(t, withPrecision(NewTimestamp(2050, 8, 5, 12, 1, 4, 0, TZAtUTC()), PrecisionMicroseconds),
+		withPrecision(NewTimestamp(2050, 8, 5, 12, 1, 4, 0, TZAtUTC()), PrecisionMilliseconds))
+	assertEquivalentTime(t, withPrecision(NewDate(2100, 1, 1), PrecisionMilliseconds), NewDate(2100, 1, 1))
+
 	assertEquivalentTime(t, NewDate(2100, 1, 1), NewDate(2100, 1, 1))
 	assertNotEquivalentTime(t, NewDate(2100, 1, 2), NewDate(2100, 1, 1))
 	assertNotEquivalentTime(t, NewDate(2100, 6, 1), NewDate(2100, 1, 1))
@@ -167,4 +176,79 @@ func TestEquivalence(t *testing.T) {
 	assertValid(t, NewTimestamp(1, 1, 1, 0, 0, 0, 0, TZWithMiutesOffsetFromUTC(-1439)))
 	assertInvalid(t, NewTimestamp(1, 1, 1, 0, 0, 0, 0, TZWithMiutesOffsetFromUTC(1440)))
 	assertInvalid(t, NewTimestamp(1, 1, 1, 0, 0, 0, 0, TZWithMiutesOffsetFromUTC(-1440)))
+
+	assertValid(t, withPrecision(NewTime(1, 1, 1, 5000000, TZAtUTC()), PrecisionMilliseconds))
+	assertValid(t, withPrecision(NewTime(1, 1, 1, 5000000, TZAtUTC()), PrecisionNanoseconds))
+	assertInvalid(t, withPrecision(NewTime(1, 1, 1, 5000, TZAtUTC()), PrecisionMilliseconds))
+	assertInvalid(t, withPrecision(NewTime(1, 1, 1, 5000000, TZAtUTC()), PrecisionSeconds))
+	assertInvalid(t, withPrecision(NewTime(1, 1, 1, 0, TZAtUTC()), PrecisionNanoseconds+1))
+	assertInvalid(t, withPrecision(NewDate(1, 1, 1), 7))
+}
+
+func assertAsCompactTime(t *testing.T, src gotime.Time, expected Time, expectedErr error) {
+	actual, err := AsCompactTime(src)
+	if expectedErr == nil && err != nil {
+		t.Errorf("Error converting %v: %v", src, err)
+		return
+	}
+	if expectedErr != nil && !errors.Is(err, expectedErr) {
+		t.Errorf("Expected converting %v to fail with %v but got %v", src, expectedErr, err)
+	}
+	if actual.Timezone.Type != expected.Timezone.Type || !expected.IsEquivalentTo(actual) {
+		t.Errorf("Expected %v to convert to %v but got %v", src, expected, actual)
+	}
+}
+
+func TestAsCompactTime(t *testing.T) {
+	berlin, err := gotime.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("Error loading location: %v", err)
+	}
+	parsedOffset, err := gotime.Parse(gotime.RFC3339, "2020-01-15T13:41:00+08:00")
+	if err != nil {
+		t.Fatalf("Error parsing time: %v", err)
+	}
+
+	assertAsCompactTime(t, gotime.Date(2020, 1, 15, 13, 41, 0, 0, gotime.UTC),
+		NewTimestamp(2020, 1, 15, 13, 41, 0, 0, TZAtUTC()), nil)
+	assertAsCompactTime(t, gotime.Date(2020, 1, 15, 13, 41, 0, 0, gotime.Local),
+		NewTimestamp(2020, 1, 15, 13, 41, 0, 0, TZLocal()), nil)
+	assertAsCompactTime(t, gotime.Date(2020, 1, 15, 13, 41, 0, 0, berlin),
+		NewTimestamp(2020, 1, 15, 13, 41, 0, 0, TZAtAreaLocation("Europe/Berlin")), nil)
+	assertAsCompactTime(t, parsedOffset,
+		NewTimestamp(2020, 1, 15, 13, 41, 0, 0, TZWithMiutesOffsetFromUTC(480)), nil)
+	assertAsCompactTime(t, gotime.Date(2020, 1, 15, 13, 41, 0, 0, gotime.FixedZone("", -330*60)),
+		NewTimestamp(2020, 1, 15, 13, 41, 0, 0, TZWithMiutesOffsetFromUTC(-330)), nil)
+	assertAsCompactTime(t, gotime.Date(2020, 1, 15, 13, 41, 0, 0, gotime.FixedZone("+08", 480*60)),
+		NewTimestamp(2020, 1, 15, 13, 41, 0, 0, TZWithMiutesOffsetFromUTC(480)), nil)
+	assertAsCompactTime(t, gotime.Date(2020, 1, 15, 13, 41, 0, 0, gotime.FixedZone("UTC-05:00", -300*60)),
+		NewTimestamp(2020, 1, 15, 13, 41, 0, 0, TZWithMiutesOffsetFromUTC(-300)), nil)
+	assertAsCompactTime(t, gotime.Date(2020, 1, 15, 13, 41, 0, 0, gotime.FixedZone("", 0)),
+		NewTimestamp(2020, 1, 15, 13, 41, 0, 0, TZAtUTC()), nil)
+	assertAsCompactTime(t, gotime.Date(2020, 1, 15, 13, 41, 0, 0, gotime.FixedZone("Etc/GMT", 0)),
+		NewTimestamp(2020, 1, 15, 13, 41, 0, 0, TZAtUTC()), nil)
+	assertAsCompactTime(t, gotime.Date(2020, 1, 15, 13, 41, 0, 0, gotime.FixedZone("My/Zone", 90*60)),
+		NewTimestamp(2020, 1, 15, 13, 41, 0, 0, TZWithMiutesOffsetFromUTC(90)), ErrorInvalidLocationName)
+
+	for _, offsetMinutes := range []int{1440, 1500, -1440} {
+		src := gotime.Date(2020, 1, 15, 13, 41, 0, 0, gotime.FixedZone("", offsetMinutes*60))
+		if actual, err := AsCompactTime(src); err == nil {
+			t.Errorf("Expected converting %v to fail but got %v", src, actual)
+		}
+	}
+}
+
+func TestLoadGoLocationCache(t *testing.T) {
+	if _, err := loadGoLocation("Not/A_Real_Zone"); err == nil {
+		t.Errorf("Expected loading an unknown location to fail")
+	}
+	if _, isCached := goLocations.Load("Not/A_Real_Zone"); isCached {
+		t.Errorf("Expected a failed location lookup not to be cached")
+	}
+	if _, err := loadGoLocation("Europe/Berlin"); err != nil {
+		t.Errorf("Error loading location: %v", err)
+	}
+	if _, isCached := goLocations.Load("Europe/Berlin"); !isCached {
+		t.Errorf("Expected a loaded location to be cached")
+	}
 }